@@ -0,0 +1,567 @@
+//    asyncpublish.go
+//    ~~~~~~~~~
+//    This module implements the asynchronous publishing features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// The error returned by PublishAsync when the publish queue is full.
+var ErrQueueFull = &GripPublishError{err: "publish queue is full"}
+
+// The error returned by PublishAsync when asynchronous publishing has not
+// been started or has been closed.
+var ErrAsyncNotRunning = &GripPublishError{err: "asynchronous publishing " +
+	"is not running"}
+
+// The AsyncConfig struct holds the settings used for asynchronous
+// publishing. Fields that are left unset use the corresponding Default*
+// value, and a negative MaxRetries disables retrying. Note that items are
+// only guaranteed to be published in order when a single worker is used.
+type AsyncConfig struct {
+	QueueSize      int
+	Workers        int
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// The default settings used for asynchronous publishing.
+const (
+	DefaultAsyncQueueSize      = 1000
+	DefaultAsyncWorkers        = 1
+	DefaultAsyncMaxRetries     = 5
+	DefaultAsyncInitialBackoff = 100 * time.Millisecond
+	DefaultAsyncMaxBackoff     = 10 * time.Second
+)
+
+// An internal struct representing a single queued publish. Durable
+// requests are held in the outbox under their sequence number, prepared
+// requests have already been sequenced, recorded requests have been
+// recorded in the history, and the tags restrict the publish to the
+// endpoints with one of them. The context is the one the request was
+// queued with, if any, without its cancellation, and is passed to the
+// interceptor chain. Delivered holds the results of the endpoints that a
+// redelivered request was already published to.
+type asyncRequest struct {
+	ctx       context.Context
+	items     []*ChannelItem
+	exports   []map[string]interface{}
+	tags      []string
+	callback  func(err error)
+	seq       uint64
+	durable   bool
+	prepared  bool
+	recorded  bool
+	delivered map[*GripPubControlClient]*EndpointResult
+}
+
+// An internal struct that owns the publish queue and its workers. Pending
+// counts the queued requests as well as the scheduled redeliveries, whose
+// timers are held so that they can be stopped when closing.
+type asyncPublisher struct {
+	config       AsyncConfig
+	queue        chan *asyncRequest
+	workers      sync.WaitGroup
+	lock         sync.Mutex
+	closed       bool
+	pending      int
+	idle         chan struct{}
+	outbox       *outbox
+	redeliveries map[*time.Timer]bool
+}
+
+// Start asynchronous publishing with the specified settings, or with the
+// default settings if config is nil. Once started, items passed to
+// PublishAsync are queued in memory and published in the background,
+// retrying transient failures with exponential backoff and jitter. Calling
+// this method while asynchronous publishing is already running has no
+// effect. If an outbox has been opened, the items that were pending in it
+// are queued first.
+func (gpc *GripPubControl) StartAsync(config *AsyncConfig) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	if gpc.async != nil {
+		return
+	}
+	ap := &asyncPublisher{redeliveries: make(map[*time.Timer]bool)}
+	if config != nil {
+		ap.config = *config
+	}
+	if ap.config.QueueSize <= 0 {
+		ap.config.QueueSize = DefaultAsyncQueueSize
+	}
+	if ap.config.Workers <= 0 {
+		ap.config.Workers = DefaultAsyncWorkers
+	}
+	if ap.config.MaxRetries < 0 {
+		ap.config.MaxRetries = 0
+	} else if ap.config.MaxRetries == 0 {
+		ap.config.MaxRetries = DefaultAsyncMaxRetries
+	}
+	if ap.config.InitialBackoff <= 0 {
+		ap.config.InitialBackoff = DefaultAsyncInitialBackoff
+	}
+	if ap.config.MaxBackoff <= 0 {
+		ap.config.MaxBackoff = DefaultAsyncMaxBackoff
+	}
+	replay := make([]*outboxRecord, 0)
+	if gpc.outbox != nil {
+		ap.outbox = gpc.outbox
+		replay = ap.outbox.replay
+		ap.outbox.replay = nil
+		gpc.outbox = nil
+	}
+	// The queue is enlarged to fit the replayed items so that the full
+	// queue size remains available for new items.
+	ap.queue = make(chan *asyncRequest, ap.config.QueueSize+len(replay))
+	for _, record := range replay {
+		ap.enqueue(record.asyncRequest())
+	}
+	if len(replay) > 0 {
+		gpc.getLogger().Info("replaying outbox", slog.Int("items",
+			len(replay)))
+	}
+	for i := 0; i < ap.config.Workers; i++ {
+		ap.workers.Add(1)
+		go ap.run(gpc)
+	}
+	gpc.async = ap
+}
+
+// Queue the specified item for publishing to the specified channel and
+// return immediately. The optional callback is called once from a
+// background goroutine with the result once the item has been published or
+// all retries have failed. If an outbox is open and the retries failed
+// with a transient error, the item stays in the outbox and is queued again
+// after the maximum backoff until it has been published or fails with an
+// error that retrying cannot fix. There is no limit on how often such an
+// item is queued again while the endpoints keep failing. ErrQueueFull is
+// returned if the queue is full and ErrAsyncNotRunning if StartAsync has
+// not been called.
+func (gpc *GripPubControl) PublishAsync(channel string,
+	item *pubcontrol.Item, callback func(err error)) error {
+	return gpc.PublishAsyncContext(context.Background(), channel, item,
+		callback)
+}
+
+// The same as PublishAsync except that the publish tags and item meta of
+// the specified context are applied to the item. They are stored with the
+// item, including in the outbox, so that they also apply when the item is
+// retried or replayed. Canceling the context has no effect on the item once
+// it has been queued, and the context is passed to the interceptor chain
+// without its cancellation.
+func (gpc *GripPubControl) PublishAsyncContext(ctx context.Context,
+	channel string, item *pubcontrol.Item, callback func(err error)) error {
+	return gpc.enqueueAsync(ctx, channel, item, callback)
+}
+
+// An internal method for exporting the specified item and adding it to the
+// queue, as well as to the outbox if one is open.
+func (gpc *GripPubControl) enqueueAsync(ctx context.Context, channel string,
+	item *pubcontrol.Item, callback func(err error)) error {
+	items := []*ChannelItem{&ChannelItem{Channel: channel, Item: item}}
+	exports, err := exportChannelItems(items)
+	if err != nil {
+		return err
+	}
+	applyItemMeta(ctx, exports[0])
+	gpc.settingsRWLock.RLock()
+	ap := gpc.async
+	gpc.settingsRWLock.RUnlock()
+	if ap == nil {
+		return ErrAsyncNotRunning
+	}
+	req := &asyncRequest{ctx: context.WithoutCancel(ctx), items: items,
+		exports: exports, tags: publishTagsFromContext(ctx),
+		callback: callback}
+	if ap.outbox != nil {
+		if req.seq, err = ap.outbox.add(exports, req.tags); err != nil {
+			return err
+		}
+		req.durable = true
+	}
+	if err = ap.enqueue(req); err != nil {
+		gpc.getLogger().WarnContext(ctx, "failed to queue publish",
+			slog.String(LogKeyChannel, channel), slog.Any("error", err))
+		if req.durable {
+			ap.outbox.ack(req.seq)
+		}
+	}
+	return err
+}
+
+// Wait until all of the items queued via PublishAsync have been processed
+// or the specified context is done. Items in the outbox that are scheduled
+// to be delivered again count as not processed, so Flush does not return
+// while the endpoints that they failed on keep failing.
+func (gpc *GripPubControl) Flush(ctx context.Context) error {
+	gpc.settingsRWLock.RLock()
+	ap := gpc.async
+	gpc.settingsRWLock.RUnlock()
+	if ap == nil {
+		return nil
+	}
+	ap.lock.Lock()
+	idle := ap.idle
+	ap.lock.Unlock()
+	if idle == nil {
+		return nil
+	}
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop accepting asynchronous publishes, wait for the queued items to be
+// processed, stop the background workers and close the outbox if one is
+// open. Items in the outbox that are scheduled to be delivered again are
+// not delivered until the outbox is next opened. StartAsync can be called
+// again afterwards.
+func (gpc *GripPubControl) Close() error {
+	gpc.settingsRWLock.Lock()
+	ap := gpc.async
+	gpc.async = nil
+	gpc.settingsRWLock.Unlock()
+	if ap == nil {
+		return nil
+	}
+	ap.lock.Lock()
+	ap.closed = true
+	for timer := range ap.redeliveries {
+		if timer.Stop() {
+			ap.doneLocked()
+		}
+	}
+	ap.redeliveries = nil
+	close(ap.queue)
+	ap.lock.Unlock()
+	ap.workers.Wait()
+	if ap.outbox != nil {
+		return ap.outbox.close()
+	}
+	return nil
+}
+
+// An internal method for adding the specified request to the queue without
+// blocking.
+func (ap *asyncPublisher) enqueue(req *asyncRequest) error {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+	if ap.closed {
+		return ErrAsyncNotRunning
+	}
+	select {
+	case ap.queue <- req:
+	default:
+		return ErrQueueFull
+	}
+	ap.addPendingLocked()
+	return nil
+}
+
+// An internal method for counting a request as pending. The lock must be
+// held by the caller.
+func (ap *asyncPublisher) addPendingLocked() {
+	if ap.pending == 0 {
+		ap.idle = make(chan struct{})
+	}
+	ap.pending++
+}
+
+// An internal method for marking a request as processed.
+func (ap *asyncPublisher) done() {
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+	ap.doneLocked()
+}
+
+// An internal method for marking a request as processed. The lock must be
+// held by the caller.
+func (ap *asyncPublisher) doneLocked() {
+	ap.pending--
+	if ap.pending == 0 {
+		close(ap.idle)
+		ap.idle = nil
+	}
+}
+
+// An internal method run by each worker goroutine.
+func (ap *asyncPublisher) run(gpc *GripPubControl) {
+	defer ap.workers.Done()
+	for req := range ap.queue {
+		// The callback is taken first since redelivered requests clear it.
+		callback := req.callback
+		err := ap.publish(gpc, req)
+		if callback != nil {
+			callback(err)
+		}
+		ap.done()
+	}
+}
+
+// An internal method for passing the specified request through the
+// interceptor chain, if there is one, and delivering it. The chain only
+// runs before the items of a request are first published, so that it
+// observes the result of delivering them including the retries, and does
+// not run again for redeliveries or items replayed from the outbox after
+// they were sequenced. A request rejected by the chain is removed from the
+// outbox.
+func (ap *asyncPublisher) publish(gpc *GripPubControl,
+	req *asyncRequest) error {
+	interceptors := gpc.getInterceptors()
+	if req.prepared || len(interceptors) == 0 {
+		_, err := ap.deliver(gpc, req)
+		return err
+	}
+	ctx := req.ctx
+	if ctx == nil {
+		ctx = WithPublishTags(context.Background(), req.tags...)
+	}
+	original := req.items[0]
+	_, arrived, errs := interceptItems(ctx, interceptors, req.items,
+		func(ctx context.Context, items []*ChannelItem,
+			ctxs []context.Context) (*PublishResult, error) {
+			if items[0].Channel != original.Channel ||
+				items[0].Item != original.Item {
+				exports, err := exportChannelItems(items)
+				if err != nil {
+					return nil, err
+				}
+				req.items, req.exports = items, exports
+			}
+			applyItemMeta(ctx, req.exports[0])
+			req.tags = publishTagsFromContext(ctx)
+			return ap.deliver(gpc, req)
+		})
+	if arrived[0] == nil && req.durable {
+		ap.outbox.ack(req.seq)
+	}
+	return errs[0]
+}
+
+// An internal method for publishing the specified request to the clients
+// that its channels are routed to according to the delivery policy and
+// returning the outcome. Only the clients that failed with a transient
+// error are retried, and retrying stops once the delivery policy is
+// satisfied. Durable requests are removed from the outbox unless a
+// transient failure prevented the delivery policy from being satisfied.
+func (ap *asyncPublisher) deliver(gpc *GripPubControl,
+	req *asyncRequest) (*PublishResult, error) {
+	ctx := context.Background()
+	if len(req.tags) > 0 {
+		ctx = WithPublishTags(ctx, req.tags...)
+	}
+	// Sequencing happens here rather than when queuing so that items that
+	// cannot be queued do not leave gaps in the sequence. The channels stay
+	// locked by the reservation until the retries have finished.
+	var reservation *sequenceReservation
+	if !req.prepared {
+		var err error
+		if reservation, err = gpc.sequenceExports(ctx,
+			req.exports); err != nil {
+			return nil, err
+		}
+		if req.durable {
+			if err := ap.outbox.prepared(req.seq, req.exports,
+				req.tags); err != nil {
+				reservation.release(nil)
+				return nil, err
+			}
+		}
+		req.prepared = true
+	}
+	targets := gpc.getPublishTargets(ctx, req.items, req.exports)
+	delivered := make([]*EndpointResult, 0, len(req.delivered))
+	if len(req.delivered) > 0 {
+		undelivered := make([]*publishTarget, 0, len(targets))
+		for _, target := range targets {
+			if result, ok := req.delivered[target.client]; ok {
+				delivered = append(delivered, result)
+			} else {
+				undelivered = append(undelivered, target)
+			}
+		}
+		targets = undelivered
+	}
+	results := make([]*EndpointResult, len(targets))
+	remaining := make([]int, len(targets))
+	for i := range targets {
+		remaining[i] = i
+	}
+	policy := gpc.getDeliveryPolicy()
+	backoff := ap.config.InitialBackoff
+	logger := gpc.getLogger()
+	for attempt := 0; len(remaining) > 0; attempt++ {
+		if attempt > 0 {
+			logger.Info("retrying publish", channelLogAttr(
+				channelItemNames(req.items)), slog.Int("attempt", attempt),
+				slog.Int("endpoints", len(remaining)))
+			time.Sleep(jitterBackoff(backoff))
+			backoff *= 2
+			if backoff > ap.config.MaxBackoff {
+				backoff = ap.config.MaxBackoff
+			}
+		}
+		attemptTargets := make([]*publishTarget, 0, len(remaining))
+		for _, i := range remaining {
+			attemptTargets = append(attemptTargets, targets[i])
+		}
+		attemptResults := deliverToTargets(ctx, policy, attemptTargets)
+		retry := make([]int, 0)
+		for j, i := range remaining {
+			results[i] = attemptResults[j]
+			if results[i].Err != nil &&
+				isTransientPublishError(results[i].Err) {
+				retry = append(retry, i)
+			}
+		}
+		if attempt >= ap.config.MaxRetries ||
+			isPolicySatisfied(policy, append(delivered, results...)) {
+			break
+		}
+		remaining = retry
+	}
+	accepted := acceptedChannels(targets, results)
+	if !req.recorded && len(accepted) > 0 {
+		gpc.recordExports(ctx, req.exports, accepted)
+		req.recorded = true
+	}
+	// The IDs of durable requests that are delivered again are kept since
+	// they are stored in the outbox.
+	redeliver := req.durable && !isPolicySatisfied(policy,
+		append(delivered, results...)) && hasTransientFailure(results)
+	if redeliver {
+		reservation.keep()
+	} else {
+		reservation.release(accepted)
+	}
+	result := &PublishResult{Endpoints: append(delivered, results...)}
+	err := aggregatePublishErrors(policy, result.Endpoints,
+		"channel: "+req.items[0].Channel)
+	if err != nil {
+		logger.Error("asynchronous publish failed", channelLogAttr(
+			channelItemNames(req.items)), slog.Any("error", err))
+	}
+	if !req.durable {
+		return result, err
+	}
+	if !redeliver {
+		if ackErr := ap.outbox.ack(req.seq); ackErr != nil && err == nil {
+			err = ackErr
+		}
+		return result, err
+	}
+	if req.delivered == nil {
+		req.delivered = make(map[*GripPubControlClient]*EndpointResult)
+	}
+	for i, target := range targets {
+		if results[i].Err == nil && !results[i].Skipped {
+			req.delivered[target.client] = results[i]
+		}
+	}
+	ap.redeliver(gpc, req)
+	return result, err
+}
+
+// An internal method for queuing the specified durable request again once
+// the maximum backoff has elapsed, so that items whose retries failed with
+// a transient error are retried while the process is running rather than
+// only when it is next started. Only the endpoints that the request has not
+// been delivered to are published to again. The callback has already been
+// called and is not called again. The scheduled redelivery counts as
+// pending until the request has been queued, and nothing is scheduled once
+// closing has started since the request stays in the outbox.
+func (ap *asyncPublisher) redeliver(gpc *GripPubControl, req *asyncRequest) {
+	req.callback = nil
+	ap.lock.Lock()
+	defer ap.lock.Unlock()
+	if ap.closed {
+		return
+	}
+	ap.addPendingLocked()
+	var timer *time.Timer
+	timer = time.AfterFunc(ap.config.MaxBackoff, func() {
+		ap.lock.Lock()
+		if ap.closed {
+			ap.doneLocked()
+			ap.lock.Unlock()
+			return
+		}
+		delete(ap.redeliveries, timer)
+		ap.lock.Unlock()
+		switch ap.enqueue(req) {
+		case nil:
+			gpc.getLogger().Info("redelivering publish", channelLogAttr(
+				channelItemNames(req.items)))
+		case ErrQueueFull:
+			ap.redeliver(gpc, req)
+		}
+		ap.done()
+	})
+	ap.redeliveries[timer] = true
+}
+
+// An internal method for determining whether any of the specified results
+// failed with a transient error or was skipped by an open circuit breaker,
+// in which case a durable request is delivered again later rather than
+// being dropped from the outbox.
+func hasTransientFailure(results []*EndpointResult) bool {
+	for _, result := range results {
+		if result.Err != nil && (isTransientPublishError(result.Err) ||
+			errors.Is(result.Err, ErrCircuitOpen)) {
+			return true
+		}
+	}
+	return false
+}
+
+// An internal method for returning a random duration between half of the
+// specified backoff and the full backoff.
+func jitterBackoff(backoff time.Duration) time.Duration {
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// An internal method for determining whether the specified publish error
+// may succeed if retried. Failure status codes other than 408, 429 and 5xx,
+// invalid items, endpoints skipped by an open circuit breaker and failures
+// caused by the caller's context being canceled or exceeding its deadline
+// are not transient, while timeouts of the endpoint, such as the timeout of
+// the HTTP client, are.
+func isTransientPublishError(err error) bool {
+	var itemErr *pubcontrol.ItemFormatError
+	if errors.As(err, &itemErr) {
+		return false
+	}
+	var endpointErr *EndpointError
+	if errors.As(err, &endpointErr) {
+		if endpointErr.callerDone ||
+			errors.Is(endpointErr.Err, ErrCircuitOpen) {
+			return false
+		}
+		if code := endpointErr.StatusCode; code != 0 {
+			return code == 408 || code == 429 || code >= 500
+		}
+		return true
+	}
+	return !errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,185 @@
+//    asyncpublish_test.go
+//    ~~~~~~~~~
+//    This module implements the asynchronous publishing tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// An internal method for creating an HTTP stream item for testing.
+func newTestItem(content string) *pubcontrol.Item {
+	return pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte(content)}}, "", "")
+}
+
+// An internal method for starting a test server that responds with each
+// of the specified status codes in turn and then with 200.
+func newTestSequenceServer(t *testing.T, codes ...int) (*httptest.Server,
+	*int32) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			call := int(atomic.AddInt32(&calls, 1))
+			if call <= len(codes) {
+				w.WriteHeader(codes[call-1])
+			}
+		}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestPublishAsync(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	assert.Equal(t, gpc.PublishAsync("chan", newTestItem("data"), nil),
+		ErrAsyncNotRunning)
+	gpc.StartAsync(nil)
+	results := make(chan error, 2)
+	callback := func(err error) { results <- err }
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), callback))
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("2"), callback))
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Nil(t, <-results)
+	assert.Nil(t, <-results)
+	assert.Equal(t, (<-requests).Items[0]["http-stream"],
+		map[string]interface{}{"content": "1"})
+	assert.Equal(t, (<-requests).Items[0]["http-stream"],
+		map[string]interface{}{"content": "2"})
+	assert.Nil(t, gpc.Close())
+	assert.Equal(t, gpc.PublishAsync("chan", newTestItem("data"), nil),
+		ErrAsyncNotRunning)
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Nil(t, gpc.Close())
+}
+
+func TestPublishAsyncRetry(t *testing.T) {
+	server, calls := newTestSequenceServer(t, 503, 500)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.StartAsync(&AsyncConfig{InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"),
+		func(err error) { results <- err }))
+	assert.Nil(t, <-results)
+	assert.Equal(t, atomic.LoadInt32(calls), int32(3))
+}
+
+func TestPublishAsyncRetryExhausted(t *testing.T) {
+	server, calls := newTestSequenceServer(t, 500, 500, 500, 500)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.StartAsync(&AsyncConfig{MaxRetries: 2,
+		InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"),
+		func(err error) { results <- err }))
+	assert.NotNil(t, <-results)
+	assert.Equal(t, atomic.LoadInt32(calls), int32(3))
+}
+
+func TestPublishAsyncRetryTimeout(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.ReadAll(r.Body)
+			if atomic.AddInt32(&calls, 1) == 1 {
+				<-r.Context().Done()
+			}
+		}))
+	t.Cleanup(server.Close)
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.httpClient.Timeout = 50 * time.Millisecond
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(gpcc)
+	gpc.StartAsync(&AsyncConfig{InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"),
+		func(err error) { results <- err }))
+	assert.Nil(t, <-results)
+	assert.Equal(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestPublishAsyncPermanentFailure(t *testing.T) {
+	server, calls := newTestSequenceServer(t, 400)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.StartAsync(&AsyncConfig{InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"),
+		func(err error) { results <- err }))
+	assert.NotNil(t, <-results)
+	assert.Equal(t, atomic.LoadInt32(calls), int32(1))
+}
+
+func TestPublishAsyncQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+	defer server.Close()
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.StartAsync(&AsyncConfig{QueueSize: 1})
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), nil))
+	var err error
+	for i := 0; i < 3 && err == nil; i++ {
+		err = gpc.PublishAsync("chan", newTestItem("2"), nil)
+	}
+	assert.Equal(t, err, ErrQueueFull)
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(gpc.Flush(ctx), context.DeadlineExceeded))
+	close(release)
+	assert.Nil(t, gpc.Close())
+}
+
+func TestIsTransientPublishError(t *testing.T) {
+	assert.True(t, isTransientPublishError(errors.New("network")))
+	assert.True(t, isTransientPublishError(
+		&EndpointError{StatusCode: 503}))
+	assert.True(t, isTransientPublishError(
+		&EndpointError{StatusCode: 429}))
+	assert.False(t, isTransientPublishError(
+		&EndpointError{StatusCode: 404}))
+	assert.False(t, isTransientPublishError(context.Canceled))
+	assert.True(t, isTransientPublishError(
+		&EndpointError{Err: context.DeadlineExceeded}))
+	assert.False(t, isTransientPublishError(
+		&EndpointError{Err: context.DeadlineExceeded, callerDone: true}))
+	assert.False(t, isTransientPublishError(
+		&pubcontrol.ItemFormatError{}))
+	assert.False(t, isTransientPublishError(
+		&EndpointError{Err: ErrCircuitOpen}))
+	assert.True(t, hasTransientFailure([]*EndpointResult{&EndpointResult{
+		Err: &EndpointError{Err: ErrCircuitOpen}}}))
+}
+
+func TestJitterBackoff(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		backoff := jitterBackoff(100 * time.Millisecond)
+		assert.True(t, backoff >= 50*time.Millisecond)
+		assert.True(t, backoff <= 100*time.Millisecond)
+	}
+	assert.Equal(t, jitterBackoff(1), time.Duration(1))
+}
@@ -0,0 +1,299 @@
+//    batch.go
+//    ~~~~~~~~~
+//    This module implements the batch publishing features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fanout/go-pubcontrol"
+	"sort"
+	"strings"
+)
+
+// The default maximum number of items that are sent to an endpoint in a
+// single publish request.
+const DefaultBatchSize = 100
+
+// The ChannelItem struct pairs an Item instance with the channel that it
+// is published to.
+type ChannelItem struct {
+	Channel string
+	Item    *pubcontrol.Item
+}
+
+// Set the maximum number of items that are sent to an endpoint in a single
+// publish request by PublishBatch. A size of zero or less restores the
+// default.
+func (gpc *GripPubControl) SetBatchSize(size int) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+	gpc.batchSize = size
+}
+
+// Publish the specified items to all of the configured endpoints. Rather
+// than making one request per item, the items are sent together in as few
+// requests per endpoint as the batch size allows. All chunks are attempted
+// and any errors are aggregated into one error that unwraps to the error
+// of each failed chunk.
+func (gpc *GripPubControl) PublishBatch(items []*ChannelItem) error {
+	return gpc.PublishBatchContext(context.Background(), items)
+}
+
+// The same as PublishBatch except that the publish requests are canceled
+// when the specified context is canceled or its deadline is exceeded.
+func (gpc *GripPubControl) PublishBatchContext(ctx context.Context,
+	items []*ChannelItem) error {
+	size := gpc.getBatchSize()
+	messages := make([]string, 0)
+	errs := make([]error, 0)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		err := gpc.publishItems(ctx, items[start:end],
+			fmt.Sprintf("batch items %d-%d", start, end-1))
+		if err != nil {
+			messages = append(messages, err.Error())
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return &GripPublishError{err: strings.Join(messages, "; "),
+			errs: errs}
+	}
+	return nil
+}
+
+// An internal method for returning the batch size.
+func (gpc *GripPubControl) getBatchSize() int {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	if gpc.batchSize <= 0 {
+		return DefaultBatchSize
+	}
+	return gpc.batchSize
+}
+
+// The ChannelResult struct holds the outcome of publishing to a single
+// channel via PublishMulti. The result holds the outcome for each of the
+// endpoints that the channel was published to, which is nil if the channel
+// was not sent, and Err is nil if the publish to the channel satisfied the
+// delivery policy.
+type ChannelResult struct {
+	Channel string
+	Result  *PublishResult
+	Err     error
+}
+
+// Publish the specified item to each of the specified channels on all of
+// the configured endpoints. The item is exported once and the channels are
+// sent together in as few requests per endpoint as the batch size allows.
+// The returned slice holds the result for each distinct channel in order,
+// based only on the endpoints that the channel was routed to, and is
+// returned even if an error occurred unless the item could not be
+// exported. Any errors are aggregated into one error that unwraps to the
+// error of each failed batch.
+func (gpc *GripPubControl) PublishMulti(channels []string,
+	item *pubcontrol.Item) ([]*ChannelResult, error) {
+	return gpc.PublishMultiContext(context.Background(), channels, item)
+}
+
+// The same as PublishMulti except that the publish requests are canceled
+// when the specified context is canceled or its deadline is exceeded.
+func (gpc *GripPubControl) PublishMultiContext(ctx context.Context,
+	channels []string, item *pubcontrol.Item) ([]*ChannelResult, error) {
+	if item == nil {
+		return nil, &GripPublishError{err: "item must not be nil"}
+	}
+	export, err := item.Export()
+	if err != nil {
+		return nil, err
+	}
+	channels = uniqueChannels(channels)
+	items := make([]*ChannelItem, 0, len(channels))
+	exports := make([]map[string]interface{}, 0, len(channels))
+	for _, channel := range channels {
+		channelExport := make(map[string]interface{}, len(export)+1)
+		for name, value := range export {
+			channelExport[name] = value
+		}
+		channelExport["channel"] = channel
+		items = append(items, &ChannelItem{Channel: channel, Item: item})
+		exports = append(exports, channelExport)
+	}
+	size := gpc.getBatchSize()
+	interceptors := gpc.getInterceptors()
+	results := make([]*ChannelResult, 0, len(channels))
+	messages := make([]string, 0)
+	errs := make([]error, 0)
+	for start := 0; start < len(items); start += size {
+		end := start + size
+		if end > len(items) {
+			end = len(items)
+		}
+		chunkResults, err := gpc.publishMultiChunk(ctx, interceptors,
+			items[start:end], exports[start:end],
+			fmt.Sprintf("channels %d-%d", start, end-1))
+		results = append(results, chunkResults...)
+		if err != nil {
+			messages = append(messages, err.Error())
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return results, &GripPublishError{err: strings.Join(messages, "; "),
+			errs: errs}
+	}
+	return results, nil
+}
+
+// An internal method for publishing the specified items of a PublishMulti
+// call together and returning the result for the channel of each item. The
+// items pass through the specified interceptors first if there are any.
+func (gpc *GripPubControl) publishMultiChunk(ctx context.Context,
+	interceptors []Interceptor, items []*ChannelItem,
+	exports []map[string]interface{},
+	target string) ([]*ChannelResult, error) {
+	policy := gpc.getDeliveryPolicy()
+	var result *PublishResult
+	var targets []*publishTarget
+	var sendErr error
+	arrived := items
+	errs := make([]error, len(items))
+	if len(interceptors) > 0 {
+		// Interceptors may change the items, which are therefore exported
+		// again after passing through the chain.
+		_, arrived, errs = interceptItems(ctx, interceptors, items,
+			func(ctx context.Context, items []*ChannelItem,
+				ctxs []context.Context) (*PublishResult, error) {
+				exports, err := exportChannelItems(items)
+				if err == nil {
+					result, targets, err = gpc.sendExports(ctx, policy,
+						items, exports, ctxs, target)
+				}
+				sendErr = err
+				return result, err
+			})
+	} else {
+		result, targets, sendErr = gpc.sendExports(ctx, policy, items,
+			exports, nil, target)
+		for i := range errs {
+			errs[i] = sendErr
+		}
+	}
+	// The results of the endpoints are grouped by the channels of the
+	// items that were sent to them.
+	endpoints := make(map[string][]*EndpointResult)
+	for i, sent := range targets {
+		for _, channel := range sent.channels() {
+			endpoints[channel] = append(endpoints[channel],
+				result.Endpoints[i])
+		}
+	}
+	results := make([]*ChannelResult, 0, len(items))
+	for i, item := range items {
+		channelResult := &ChannelResult{Channel: item.Channel, Err: errs[i]}
+		// Items that reached the sender get the outcome of their own
+		// endpoints unless an interceptor returned an error of its own.
+		if arrived[i] != nil && result != nil &&
+			(errs[i] == nil || errors.Is(errs[i], sendErr)) {
+			channelEndpoints := endpoints[arrived[i].Channel]
+			if channelEndpoints == nil {
+				channelEndpoints = make([]*EndpointResult, 0)
+			}
+			channelResult.Result = &PublishResult{Endpoints: channelEndpoints}
+			channelResult.Err = aggregatePublishErrors(policy,
+				channelEndpoints, "channel: "+arrived[i].Channel)
+		}
+		results = append(results, channelResult)
+	}
+	if len(interceptors) > 0 {
+		return results, joinInterceptedErrors(errs)
+	}
+	return results, sendErr
+}
+
+// An internal method for returning the distinct specified channels in
+// order.
+func uniqueChannels(channels []string) []string {
+	unique := make([]string, 0, len(channels))
+	seen := make(map[string]bool)
+	for _, channel := range channels {
+		if !seen[channel] {
+			seen[channel] = true
+			unique = append(unique, channel)
+		}
+	}
+	return unique
+}
+
+// An internal method for exporting the specified items and setting the
+// channel of each export.
+func exportChannelItems(
+	items []*ChannelItem) ([]map[string]interface{}, error) {
+	exports := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		if item == nil || item.Item == nil {
+			return nil, &GripPublishError{err: "items must not be nil"}
+		}
+		export, err := item.Item.Export()
+		if err != nil {
+			return nil, err
+		}
+		export["channel"] = item.Channel
+		exports = append(exports, export)
+	}
+	return exports, nil
+}
+
+// An internal struct implementing the Formatter interface for a format
+// that has already been exported, so that exported items can be published
+// via wrapped PubControlClient instances.
+type exportedFormat struct {
+	name  string
+	value interface{}
+}
+
+// The name of the format.
+func (format *exportedFormat) Name() string {
+	return format.name
+}
+
+// Return the exported value as is.
+func (format *exportedFormat) Export() interface{} {
+	return format.value
+}
+
+// An internal method for rebuilding a ChannelItem from the specified
+// exported item.
+func exportedChannelItem(export map[string]interface{}) *ChannelItem {
+	formats := make([]pubcontrol.Formatter, 0, len(export))
+	names := make([]string, 0, len(export))
+	for name := range export {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		switch name {
+		case "channel", "id", "prev-id":
+		default:
+			formats = append(formats, &exportedFormat{name: name,
+				value: export[name]})
+		}
+	}
+	channel, _ := export["channel"].(string)
+	id, _ := export["id"].(string)
+	prevId, _ := export["prev-id"].(string)
+	return &ChannelItem{Channel: channel,
+		Item: pubcontrol.NewItem(formats, id, prevId)}
+}
@@ -0,0 +1,195 @@
+//    batch_test.go
+//    ~~~~~~~~~
+//    This module implements the batch publishing tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+// An internal method for creating the specified number of HTTP stream
+// items published to numbered channels.
+func newTestChannelItems(count int) []*ChannelItem {
+	items := make([]*ChannelItem, 0, count)
+	for i := 0; i < count; i++ {
+		items = append(items, &ChannelItem{Channel: "chan" + strconv.Itoa(i),
+			Item: pubcontrol.NewItem([]pubcontrol.Formatter{
+				&HttpStreamFormat{Content: []byte("data")}}, "", "")})
+	}
+	return items
+}
+
+func TestPublishBatch(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.SetBatchSize(2)
+	assert.Nil(t, gpc.PublishBatch(newTestChannelItems(5)))
+	assert.Equal(t, len(requests), 3)
+	req := <-requests
+	assert.Equal(t, req.Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan0",
+			"http-stream": map[string]interface{}{"content": "data"}},
+		map[string]interface{}{"channel": "chan1",
+			"http-stream": map[string]interface{}{"content": "data"}}})
+	assert.Equal(t, len((<-requests).Items), 2)
+	req = <-requests
+	assert.Equal(t, len(req.Items), 1)
+	assert.Equal(t, req.Items[0]["channel"], "chan4")
+	gpc.SetBatchSize(0)
+	assert.Nil(t, gpc.PublishBatchContext(context.Background(),
+		newTestChannelItems(DefaultBatchSize+1)))
+	assert.Equal(t, len((<-requests).Items), DefaultBatchSize)
+	assert.Equal(t, len((<-requests).Items), 1)
+}
+
+func TestPublishBatchWrappedClient(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddClient(pubcontrol.NewPubControlClient(server.URL))
+	assert.Nil(t, gpc.PublishBatch(newTestChannelItems(3)))
+	assert.Equal(t, len(requests), 3)
+}
+
+func TestPublishBatchFailure(t *testing.T) {
+	server, _ := newTestPublishServer(t, 500)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.SetBatchSize(2)
+	err := gpc.PublishBatch(newTestChannelItems(3))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "batch items 0-1")
+	assert.Contains(t, err.Error(), "batch items 2-2")
+	assert.True(t, errors.Is(err, ErrEndpointFailed))
+	assert.NotNil(t, gpc.PublishBatch([]*ChannelItem{nil}))
+}
+
+func TestExportChannelItems(t *testing.T) {
+	exports, err := exportChannelItems(newTestChannelItems(1))
+	assert.Nil(t, err)
+	assert.Equal(t, exports, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan0",
+			"http-stream": map[string]interface{}{"content": "data"}}})
+	exports, err = exportChannelItems([]*ChannelItem{&ChannelItem{
+		Channel: "chan", Item: pubcontrol.NewItem([]pubcontrol.Formatter{
+			&HttpStreamFormat{}, &HttpStreamFormat{}}, "", "")}})
+	assert.Nil(t, exports)
+	assert.NotNil(t, err)
+	exports, err = exportChannelItems([]*ChannelItem{
+		&ChannelItem{Channel: "chan"}})
+	assert.Nil(t, exports)
+	assert.NotNil(t, err)
+}
+
+func TestPublishMulti(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.SetBatchSize(2)
+	gpc.SetSequencer(NewSequencer(nil))
+	results, err := gpc.PublishMulti([]string{"a", "b", "a", "c"},
+		newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(results), 3)
+	assert.Equal(t, results[0].Channel, "a")
+	assert.Equal(t, results[1].Channel, "b")
+	assert.Equal(t, results[2].Channel, "c")
+	assert.Equal(t, results[0].Result, results[1].Result)
+	assert.Equal(t, results[2].Result.Succeeded(), 1)
+	assert.Nil(t, results[2].Err)
+	assert.Equal(t, len(requests), 2)
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "a", "id": "1",
+			"http-stream": map[string]interface{}{"content": "data"}},
+		map[string]interface{}{"channel": "b", "id": "1",
+			"http-stream": map[string]interface{}{"content": "data"}}})
+	assert.Equal(t, (<-requests).Items[0]["channel"], "c")
+	results, err = gpc.PublishMulti(nil, newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+	results, err = gpc.PublishMulti([]string{"a"}, nil)
+	assert.Nil(t, results)
+	assert.NotNil(t, err)
+}
+
+func TestPublishMultiFailure(t *testing.T) {
+	server, _ := newTestPublishServer(t, 500)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	gpc.SetBatchSize(2)
+	results, err := gpc.PublishMultiContext(context.Background(),
+		[]string{"a", "b", "c"}, newTestItem("data"))
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "channels 0-1")
+	assert.Contains(t, err.Error(), "channels 2-2")
+	assert.True(t, errors.Is(err, ErrEndpointFailed))
+	assert.Equal(t, len(results), 3)
+	for _, result := range results {
+		assert.True(t, errors.Is(result.Err, ErrEndpointFailed))
+		assert.Equal(t, len(result.Result.Failed()), 1)
+	}
+}
+
+func TestPublishMultiInterceptor(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	rejected := errors.New("rejected")
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		if channel == "b" {
+			return nil, rejected
+		}
+		return next(ctx, channel, item)
+	})
+	results, err := gpc.PublishMulti([]string{"a", "b"}, newTestItem("data"))
+	assert.True(t, errors.Is(err, rejected))
+	assert.Equal(t, len(results), 2)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, results[0].Result.Succeeded(), 1)
+	assert.True(t, errors.Is(results[1].Err, rejected))
+	assert.Nil(t, results[1].Result)
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "a",
+			"http-stream": map[string]interface{}{"content": "data"}}})
+}
+
+func TestPublishMultiRouted(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 500)
+	gpc.SetRouter(ChannelRouterFunc(func(channel string,
+		clients []*GripPubControlClient) []*GripPubControlClient {
+		if channel == "good" {
+			return clients[:1]
+		}
+		return clients[1:]
+	}))
+	results, err := gpc.PublishMulti([]string{"good", "bad"},
+		newTestItem("data"))
+	assert.True(t, errors.Is(err, ErrEndpointFailed))
+	assert.Equal(t, len(results), 2)
+	assert.Nil(t, results[0].Err)
+	assert.Equal(t, len(results[0].Result.Endpoints), 1)
+	assert.Equal(t, results[0].Result.Endpoints[0].Uri, gpc.clients[0].Uri())
+	assert.True(t, errors.Is(results[1].Err, ErrEndpointFailed))
+	assert.Equal(t, len(results[1].Result.Failed()), 1)
+	assert.Equal(t, (<-requests[0]).Items[0]["channel"], "good")
+	assert.Equal(t, (<-requests[1]).Items[0]["channel"], "bad")
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		return next(ctx, channel, item)
+	})
+	results, err = gpc.PublishMulti([]string{"good", "bad"},
+		newTestItem("data"))
+	assert.NotNil(t, err)
+	assert.Nil(t, results[0].Err)
+	assert.True(t, errors.Is(results[1].Err, ErrEndpointFailed))
+}
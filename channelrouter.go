@@ -0,0 +1,162 @@
+//    channelrouter.go
+//    ~~~~~~~~~
+//    This module implements the ChannelRouter interface and the consistent
+//    hashing router.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The ChannelRouter interface is used to shard publishing across the
+// configured clients. When a router is set on a GripPubControl instance,
+// items are only published to the clients that the router returns for the
+// channel of the item.
+type ChannelRouter interface {
+
+	// Return the subset of the specified clients that hold the subscribers
+	// of the specified channel.
+	Route(channel string,
+		clients []*GripPubControlClient) []*GripPubControlClient
+}
+
+// The ChannelRouterFunc type allows an ordinary function to be used as a
+// ChannelRouter.
+type ChannelRouterFunc func(channel string,
+	clients []*GripPubControlClient) []*GripPubControlClient
+
+// Call the underlying function with the specified channel and clients.
+func (f ChannelRouterFunc) Route(channel string,
+	clients []*GripPubControlClient) []*GripPubControlClient {
+	return f(channel, clients)
+}
+
+// Set the router used to determine which clients each channel is published
+// to. Setting a nil router publishes every channel to every client.
+func (gpc *GripPubControl) SetRouter(router ChannelRouter) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	gpc.router = router
+}
+
+// An internal method for returning the router used for publishing.
+func (gpc *GripPubControl) getRouter() ChannelRouter {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	return gpc.router
+}
+
+// The number of points that each client occupies on the hash ring.
+const hashRingPointsPerClient = 160
+
+// An internal struct representing a point on the hash ring.
+type hashRingPoint struct {
+	hash  uint32
+	index int
+}
+
+// An internal struct implementing a consistent hashing ChannelRouter.
+type consistentHashRouter struct {
+	replicas  int
+	lock      sync.Mutex
+	signature string
+	ring      []hashRingPoint
+}
+
+// Create a ChannelRouter that maps each channel to the specified number of
+// clients using consistent hashing, so that adding or removing a client
+// only moves the channels of that client. Clients are identified by their
+// name, or by their URI if they are anonymous. A replica count of less than
+// one is treated as one.
+func NewConsistentHashRouter(replicas int) ChannelRouter {
+	if replicas < 1 {
+		replicas = 1
+	}
+	return &consistentHashRouter{replicas: replicas}
+}
+
+// Return the clients for the specified channel by walking the hash ring
+// clockwise from the hash of the channel.
+func (router *consistentHashRouter) Route(channel string,
+	clients []*GripPubControlClient) []*GripPubControlClient {
+	if len(clients) == 0 {
+		return nil
+	}
+	ring := router.getRing(clients)
+	hash := hashRingKey(channel)
+	start := sort.Search(len(ring), func(i int) bool {
+		return ring[i].hash >= hash
+	})
+	count := router.replicas
+	if count > len(clients) {
+		count = len(clients)
+	}
+	seen := make(map[int]bool)
+	out := make([]*GripPubControlClient, 0, count)
+	for i := 0; i < len(ring) && len(out) < count; i++ {
+		point := ring[(start+i)%len(ring)]
+		if !seen[point.index] {
+			seen[point.index] = true
+			out = append(out, clients[point.index])
+		}
+	}
+	return out
+}
+
+// An internal method for returning the hash ring for the specified
+// clients. The ring is rebuilt only when the clients change.
+func (router *consistentHashRouter) getRing(
+	clients []*GripPubControlClient) []hashRingPoint {
+	keys := make([]string, 0, len(clients))
+	for _, client := range clients {
+		keys = append(keys, client.routingKey())
+	}
+	signature := strings.Join(keys, "\n")
+	router.lock.Lock()
+	defer router.lock.Unlock()
+	if router.ring != nil && router.signature == signature {
+		return router.ring
+	}
+	ring := make([]hashRingPoint, 0, len(keys)*hashRingPointsPerClient)
+	for index, key := range keys {
+		for i := 0; i < hashRingPointsPerClient; i++ {
+			ring = append(ring, hashRingPoint{
+				hash: hashRingKey(key + "#" + strconv.Itoa(i)), index: index})
+		}
+	}
+	sort.Slice(ring, func(i, j int) bool {
+		return ring[i].hash < ring[j].hash
+	})
+	router.signature = signature
+	router.ring = ring
+	return ring
+}
+
+// An internal method for hashing a key onto the hash ring.
+func hashRingKey(key string) uint32 {
+	hash := fnv.New32a()
+	hash.Write([]byte(key))
+	return hash.Sum32()
+}
+
+// An internal method for returning the key used to identify the client
+// when routing. Anonymous wrapped PubControlClient instances have no URI
+// and are identified by their address instead.
+func (gpcc *GripPubControlClient) routingKey() string {
+	if name := gpcc.Name(); name != "" {
+		return name
+	}
+	if gpcc.uri != "" {
+		return gpcc.uri
+	}
+	return fmt.Sprintf("%p", gpcc)
+}
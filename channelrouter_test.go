@@ -0,0 +1,101 @@
+//    channelrouter_test.go
+//    ~~~~~~~~~
+//    This module implements the ChannelRouter tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+// An internal method for creating clients with numbered URIs.
+func newTestRouterClients(count int) []*GripPubControlClient {
+	clients := make([]*GripPubControlClient, 0, count)
+	for i := 0; i < count; i++ {
+		clients = append(clients,
+			NewGripPubControlClient("http://pushpin"+strconv.Itoa(i)))
+	}
+	return clients
+}
+
+func TestConsistentHashRouter(t *testing.T) {
+	router := NewConsistentHashRouter(1)
+	clients := newTestRouterClients(4)
+	assert.Nil(t, router.Route("chan", nil))
+	counts := make(map[*GripPubControlClient]int)
+	routes := make(map[string]*GripPubControlClient)
+	for i := 0; i < 1000; i++ {
+		channel := "chan" + strconv.Itoa(i)
+		routed := router.Route(channel, clients)
+		assert.Equal(t, len(routed), 1)
+		assert.Equal(t, router.Route(channel, clients), routed)
+		counts[routed[0]]++
+		routes[channel] = routed[0]
+	}
+	for _, client := range clients {
+		assert.True(t, counts[client] > 100)
+	}
+	moved := 0
+	for channel, client := range routes {
+		routed := router.Route(channel, clients[:3])
+		if routed[0] != client {
+			assert.Equal(t, client, clients[3])
+			moved++
+		}
+	}
+	assert.Equal(t, moved, counts[clients[3]])
+}
+
+func TestConsistentHashRouterReplicas(t *testing.T) {
+	clients := newTestRouterClients(3)
+	routed := NewConsistentHashRouter(2).Route("chan", clients)
+	assert.Equal(t, len(routed), 2)
+	assert.NotEqual(t, routed[0], routed[1])
+	assert.Equal(t, len(NewConsistentHashRouter(5).Route("chan", clients)), 3)
+	assert.Equal(t, len(NewConsistentHashRouter(0).Route("chan", clients)), 1)
+}
+
+func TestSetRouter(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 200)
+	gpc.SetRouter(ChannelRouterFunc(func(channel string,
+		clients []*GripPubControlClient) []*GripPubControlClient {
+		if channel == "a" {
+			return clients[:1]
+		} else if channel == "b" {
+			return []*GripPubControlClient{clients[1], clients[1]}
+		}
+		return nil
+	}))
+	assert.Nil(t, gpc.PublishHttpStream("a", "data", "", ""))
+	assert.Equal(t, len(requests[0]), 1)
+	assert.Equal(t, len(requests[1]), 0)
+	<-requests[0]
+	assert.Nil(t, gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "a", Item: newTestItem("1")},
+		&ChannelItem{Channel: "b", Item: newTestItem("2")},
+		&ChannelItem{Channel: "b", Item: newTestItem("3")},
+		&ChannelItem{Channel: "c", Item: newTestItem("4")}}))
+	req := <-requests[0]
+	assert.Equal(t, len(req.Items), 1)
+	assert.Equal(t, req.Items[0]["channel"], "a")
+	req = <-requests[1]
+	assert.Equal(t, len(req.Items), 2)
+	assert.Equal(t, req.Items[0]["channel"], "b")
+	assert.Equal(t, req.Items[1]["channel"], "b")
+	gpc.SetRouter(nil)
+	assert.Nil(t, gpc.PublishHttpStream("c", "data", "", ""))
+	assert.Equal(t, len(requests[0]), 1)
+	assert.Equal(t, len(requests[1]), 1)
+}
+
+func TestRoutingKey(t *testing.T) {
+	assert.Equal(t, NewGripPubControlClient("http://a").routingKey(),
+		"http://a")
+	wrapped := wrapPubControlClient(nil)
+	assert.NotEqual(t, wrapped.routingKey(), "")
+}
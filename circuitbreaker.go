@@ -0,0 +1,213 @@
+//    circuitbreaker.go
+//    ~~~~~~~~~
+//    This module implements the endpoint health tracking and circuit
+//    breaker features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// The error that EndpointErrors wrap when an endpoint was not published to
+// because its circuit breaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// The default amount of time that a circuit breaker stays open before a
+// publish is let through to probe whether the endpoint has recovered.
+const DefaultProbeInterval = 10 * time.Second
+
+// The CircuitState type represents the state of the circuit breaker of an
+// endpoint.
+type CircuitState int
+
+const (
+	// The endpoint is healthy and is published to.
+	CircuitClosed CircuitState = iota
+
+	// The endpoint has failed repeatedly and is not published to.
+	CircuitOpen
+
+	// The endpoint is being probed by a single publish to determine
+	// whether it has recovered.
+	CircuitHalfOpen
+)
+
+// Return the name of the circuit state.
+func (state CircuitState) String() string {
+	switch state {
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// The ClientHealth struct describes the health of a single endpoint as
+// tracked by its circuit breaker.
+type ClientHealth struct {
+	Uri                 string
+	Name                string
+	State               CircuitState
+	ConsecutiveFailures int
+	LastFailure         time.Time
+	LastError           error
+}
+
+// An internal struct used to track the health of an endpoint. A threshold
+// of zero disables the circuit breaker while still tracking failures.
+type circuitBreaker struct {
+	lock          sync.Mutex
+	threshold     int
+	probeInterval time.Duration
+	state         CircuitState
+	failures      int
+	openedAt      time.Time
+	lastFailure   time.Time
+	lastErr       error
+}
+
+// Enable the circuit breaker for the endpoint so that it is skipped after
+// the specified number of consecutive failures. Once open, a single
+// publish is let through after each probe interval to check whether the
+// endpoint has recovered, and the circuit closes if it succeeds. Probing
+// is lazy: no requests are sent in the background, so the probe is the
+// first publish made after the probe interval has elapsed. A threshold of
+// zero or less disables the circuit breaker, and a probe interval of zero
+// or less uses DefaultProbeInterval.
+func (gpcc *GripPubControlClient) SetCircuitBreaker(threshold int,
+	probeInterval time.Duration) {
+	cb := gpcc.breaker
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if threshold < 0 {
+		threshold = 0
+	}
+	if probeInterval <= 0 {
+		probeInterval = DefaultProbeInterval
+	}
+	cb.threshold = threshold
+	cb.probeInterval = probeInterval
+	if threshold == 0 || cb.failures < threshold {
+		cb.state = CircuitClosed
+	}
+}
+
+// Return the current health of the endpoint.
+func (gpcc *GripPubControlClient) Health() ClientHealth {
+	name := gpcc.Name()
+	cb := gpcc.breaker
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	return ClientHealth{Uri: gpcc.uri, Name: name, State: cb.state,
+		ConsecutiveFailures: cb.failures, LastFailure: cb.lastFailure,
+		LastError: cb.lastErr}
+}
+
+// Enable the circuit breaker for all current and future clients. See
+// GripPubControlClient.SetCircuitBreaker for details.
+func (gpc *GripPubControl) SetCircuitBreaker(threshold int,
+	probeInterval time.Duration) {
+	gpc.settingsRWLock.Lock()
+	gpc.circuitThreshold = threshold
+	gpc.circuitProbeInterval = probeInterval
+	gpc.settingsRWLock.Unlock()
+	gpc.clientsRWLock.RLock()
+	defer gpc.clientsRWLock.RUnlock()
+	for _, gpcc := range gpc.clients {
+		gpcc.SetCircuitBreaker(threshold, probeInterval)
+	}
+}
+
+// An internal method for applying the circuit breaker settings of this
+// GripPubControl instance to the specified client if SetCircuitBreaker has
+// been called.
+func (gpc *GripPubControl) applyCircuitBreaker(gpcc *GripPubControlClient) {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	if gpc.circuitThreshold > 0 {
+		gpcc.SetCircuitBreaker(gpc.circuitThreshold, gpc.circuitProbeInterval)
+	}
+}
+
+// Return the current health of each configured client in the order in
+// which the clients were added.
+func (gpc *GripPubControl) Health() []ClientHealth {
+	gpc.clientsRWLock.RLock()
+	defer gpc.clientsRWLock.RUnlock()
+	health := make([]ClientHealth, 0, len(gpc.clients))
+	for _, gpcc := range gpc.clients {
+		health = append(health, gpcc.Health())
+	}
+	return health
+}
+
+// An internal method for determining whether a publish may be sent to the
+// endpoint. An open circuit moves to half-open once the probe interval has
+// elapsed, in which case only the caller that made the transition may
+// publish.
+func (cb *circuitBreaker) allow() bool {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	switch cb.state {
+	case CircuitOpen:
+		if time.Since(cb.openedAt) < cb.probeInterval {
+			return false
+		}
+		cb.state = CircuitHalfOpen
+		return true
+	case CircuitHalfOpen:
+		return false
+	default:
+		return true
+	}
+}
+
+// An internal method for recording the outcome of a publish. Failure
+// status codes other than 4xx and errors without a response, including
+// timeouts of the endpoint, count as failures. Failures caused by the
+// caller's own context being canceled or exceeding its deadline say nothing
+// about the health of the endpoint and are ignored.
+func (cb *circuitBreaker) record(err error) {
+	cb.lock.Lock()
+	defer cb.lock.Unlock()
+	if err == nil || errors.Is(err, ErrEndpointRejected) {
+		cb.state = CircuitClosed
+		cb.failures = 0
+		return
+	}
+	if isCallerDoneError(err) {
+		if cb.state == CircuitHalfOpen {
+			cb.state = CircuitOpen
+		}
+		return
+	}
+	cb.failures++
+	cb.lastFailure = time.Now()
+	cb.lastErr = err
+	if cb.threshold > 0 && (cb.state == CircuitHalfOpen ||
+		cb.failures >= cb.threshold) {
+		cb.state = CircuitOpen
+		cb.openedAt = cb.lastFailure
+	}
+}
+
+// An internal method for determining whether the specified publish error
+// was caused by the caller's context being canceled or exceeding its
+// deadline.
+func isCallerDoneError(err error) bool {
+	var endpointErr *EndpointError
+	if errors.As(err, &endpointErr) {
+		return endpointErr.callerDone
+	}
+	return errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,141 @@
+//    circuitbreaker_test.go
+//    ~~~~~~~~~
+//    This module implements the circuit breaker tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitStateString(t *testing.T) {
+	assert.Equal(t, CircuitClosed.String(), "closed")
+	assert.Equal(t, CircuitOpen.String(), "open")
+	assert.Equal(t, CircuitHalfOpen.String(), "half-open")
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	server, calls := newTestSequenceServer(t, 500, 500, 500)
+	gpc := NewGripPubControl(nil)
+	gpc.SetCircuitBreaker(2, 20*time.Millisecond)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, gpc.Health()[0].State, CircuitClosed)
+	assert.Equal(t, gpc.Health()[0].ConsecutiveFailures, 1)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	health := gpc.Health()[0]
+	assert.Equal(t, health.Uri, server.URL)
+	assert.Equal(t, health.State, CircuitOpen)
+	assert.Equal(t, health.ConsecutiveFailures, 2)
+	assert.True(t, errors.Is(health.LastError, ErrEndpointFailed))
+	err := gpc.PublishHttpStream("chan", "data", "", "")
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.Equal(t, atomic.LoadInt32(calls), int32(2))
+	time.Sleep(25 * time.Millisecond)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, atomic.LoadInt32(calls), int32(3))
+	assert.Equal(t, gpc.Health()[0].State, CircuitOpen)
+	time.Sleep(25 * time.Millisecond)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, gpc.Health()[0].State, CircuitClosed)
+	assert.Equal(t, gpc.Health()[0].ConsecutiveFailures, 0)
+}
+
+func TestCircuitBreakerSkipsOpenClient(t *testing.T) {
+	okServer, _ := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(okServer.URL))
+	gpc.AddGripClient(NewGripPubControlClient("http://127.0.0.1:1"))
+	gpc.SetCircuitBreaker(1, time.Hour)
+	gpc.SetDeliveryPolicy(DeliverAny)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, gpc.Health()[1].State, CircuitOpen)
+	result, err := gpc.PublishWithResult(context.Background(), "chan",
+		newTestItem("data"))
+	assert.Nil(t, err)
+	assert.True(t, errors.Is(result.Endpoints[1].Err, ErrCircuitOpen))
+	assert.Equal(t, result.Endpoints[1].Latency, time.Duration(0))
+}
+
+func TestCircuitBreakerIgnoresNonFailures(t *testing.T) {
+	server, _ := newTestSequenceServer(t, 500, 404)
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.SetCircuitBreaker(2, time.Hour)
+	assert.NotNil(t, gpcc.Publish("chan", newTestItem("data")))
+	assert.Equal(t, gpcc.Health().ConsecutiveFailures, 1)
+	assert.NotNil(t, gpcc.Publish("chan", newTestItem("data")))
+	assert.Equal(t, gpcc.Health().ConsecutiveFailures, 0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, gpcc.PublishContext(ctx, "chan", newTestItem("data")))
+	assert.Equal(t, gpcc.Health().ConsecutiveFailures, 0)
+	gpcc.SetCircuitBreaker(0, 0)
+	for i := 0; i < 3; i++ {
+		gpcc.breaker.record(&EndpointError{StatusCode: 500})
+	}
+	assert.Equal(t, gpcc.Health().State, CircuitClosed)
+	assert.Equal(t, gpcc.Health().ConsecutiveFailures, 3)
+}
+
+// An internal type for an HTTP transport that panics on the specified
+// call and otherwise uses the default transport.
+type panickingTransport struct {
+	calls   int32
+	panicAt int32
+}
+
+// The method that panics instead of making the specified request if it is
+// the call that is to panic.
+func (pt *panickingTransport) RoundTrip(
+	req *http.Request) (*http.Response, error) {
+	if atomic.AddInt32(&pt.calls, 1) == pt.panicAt {
+		panic("transport panicked")
+	}
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestCircuitBreakerProbePanic(t *testing.T) {
+	server, _ := newTestSequenceServer(t, 500)
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.httpClient.Transport = &panickingTransport{panicAt: 2}
+	gpcc.SetCircuitBreaker(1, 10*time.Millisecond)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(gpcc)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, gpcc.Health().State, CircuitOpen)
+	time.Sleep(15 * time.Millisecond)
+	err := gpc.PublishHttpStream("chan", "data", "", "")
+	assert.Contains(t, err.Error(), "PANIC: transport panicked")
+	health := gpcc.Health()
+	assert.Equal(t, health.State, CircuitOpen)
+	assert.Contains(t, health.LastError.Error(), "transport panicked")
+	time.Sleep(15 * time.Millisecond)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, gpcc.Health().State, CircuitClosed)
+}
+
+func TestCircuitBreakerOpensOnTimeouts(t *testing.T) {
+	gpcc := newTestHangingClient(t, 50*time.Millisecond)
+	gpcc.SetCircuitBreaker(2, time.Hour)
+	for i := 0; i < 4; i++ {
+		err := gpcc.Publish("chan", newTestItem("data"))
+		if i < 2 {
+			assert.True(t, errors.Is(err, ErrEndpointTimeout))
+		} else {
+			assert.True(t, errors.Is(err, ErrCircuitOpen))
+		}
+	}
+	health := gpcc.Health()
+	assert.Equal(t, health.State, CircuitOpen)
+	assert.Equal(t, health.ConsecutiveFailures, 2)
+	assert.True(t, errors.Is(health.LastError, ErrEndpointTimeout))
+}
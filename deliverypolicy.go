@@ -0,0 +1,88 @@
+//    deliverypolicy.go
+//    ~~~~~~~~~
+//    This module implements the DeliveryPolicy type and features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import "context"
+
+// The DeliveryPolicy type determines which of the configured endpoints a
+// publish is sent to and how many of them must succeed for the publish to
+// be considered successful.
+type DeliveryPolicy int
+
+const (
+	// Publish to every endpoint and require all of them to succeed. This
+	// is the default policy.
+	DeliverAll DeliveryPolicy = iota
+
+	// Publish to every endpoint and require at least one to succeed.
+	DeliverAny
+
+	// Publish to every endpoint and require more than half of them to
+	// succeed.
+	DeliverQuorum
+
+	// Publish to the endpoints one at a time in the order they were added,
+	// stopping at the first one that succeeds.
+	DeliverFailover
+)
+
+// Set the delivery policy used for publishing.
+func (gpc *GripPubControl) SetDeliveryPolicy(policy DeliveryPolicy) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	gpc.deliveryPolicy = policy
+}
+
+// An internal method for returning the delivery policy used for
+// publishing.
+func (gpc *GripPubControl) getDeliveryPolicy() DeliveryPolicy {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	return gpc.deliveryPolicy
+}
+
+// An internal method for publishing to the specified targets according to
+// the specified policy. The returned slice holds the result for the target
+// at the same index, and targets that were not published to under the
+// failover policy are marked as skipped.
+func deliverToTargets(ctx context.Context, policy DeliveryPolicy,
+	targets []*publishTarget) []*EndpointResult {
+	if policy != DeliverFailover {
+		return publishToTargets(ctx, targets)
+	}
+	results := make([]*EndpointResult, len(targets))
+	delivered := false
+	for i, target := range targets {
+		if delivered {
+			results[i] = &EndpointResult{Uri: target.client.uri,
+				Name: target.client.Name(), Skipped: true}
+			continue
+		}
+		results[i] = publishToTargets(ctx, targets[i:i+1])[0]
+		delivered = results[i].Err == nil
+	}
+	return results
+}
+
+// An internal method for determining whether the specified results satisfy
+// the specified policy. Publishing to no endpoints always succeeds.
+func isPolicySatisfied(policy DeliveryPolicy,
+	results []*EndpointResult) bool {
+	if len(results) == 0 {
+		return true
+	}
+	succeeded := (&PublishResult{Endpoints: results}).Succeeded()
+	switch policy {
+	case DeliverAny, DeliverFailover:
+		return succeeded > 0
+	case DeliverQuorum:
+		return succeeded > len(results)/2
+	default:
+		return succeeded == len(results)
+	}
+}
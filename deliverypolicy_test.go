@@ -0,0 +1,109 @@
+//    deliverypolicy_test.go
+//    ~~~~~~~~~
+//    This module implements the DeliveryPolicy tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// An internal method for creating a GripPubControl instance with one
+// endpoint per specified status code.
+func newTestPolicyPubControl(t *testing.T,
+	codes ...int) (*GripPubControl, []chan *testPublishRequest) {
+	gpc := NewGripPubControl(nil)
+	requests := make([]chan *testPublishRequest, 0, len(codes))
+	for _, code := range codes {
+		server, serverRequests := newTestPublishServer(t, code)
+		gpc.AddGripClient(NewGripPubControlClient(server.URL))
+		requests = append(requests, serverRequests)
+	}
+	return gpc, requests
+}
+
+func TestDeliverAll(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 200, 200)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	gpc, _ = newTestPolicyPubControl(t, 200, 500)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+}
+
+func TestDeliverAny(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 500, 200, 500)
+	gpc.SetDeliveryPolicy(DeliverAny)
+	result, err := gpc.PublishWithResult(context.Background(), "chan",
+		newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Failed()), 2)
+	gpc, _ = newTestPolicyPubControl(t, 500, 500)
+	gpc.SetDeliveryPolicy(DeliverAny)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+}
+
+func TestDeliverQuorum(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 200, 200, 500)
+	gpc.SetDeliveryPolicy(DeliverQuorum)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	gpc, _ = newTestPolicyPubControl(t, 200, 500, 500)
+	gpc.SetDeliveryPolicy(DeliverQuorum)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	gpc, _ = newTestPolicyPubControl(t, 200, 200, 500, 500)
+	gpc.SetDeliveryPolicy(DeliverQuorum)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+}
+
+func TestDeliverFailover(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 500, 200, 200)
+	gpc.SetDeliveryPolicy(DeliverFailover)
+	result, err := gpc.PublishWithResult(context.Background(), "chan",
+		newTestItem("data"))
+	assert.Nil(t, err)
+	assert.NotNil(t, result.Endpoints[0].Err)
+	assert.Nil(t, result.Endpoints[1].Err)
+	assert.True(t, result.Endpoints[2].Skipped)
+	assert.Equal(t, result.Succeeded(), 1)
+	assert.Equal(t, len(requests[0]), 1)
+	assert.Equal(t, len(requests[1]), 1)
+	assert.Equal(t, len(requests[2]), 0)
+	gpc, _ = newTestPolicyPubControl(t, 500, 500)
+	gpc.SetDeliveryPolicy(DeliverFailover)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+}
+
+func TestDeliverAsyncPolicy(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 500)
+	gpc.SetDeliveryPolicy(DeliverAny)
+	gpc.StartAsync(&AsyncConfig{InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"),
+		func(err error) { results <- err }))
+	assert.Nil(t, <-results)
+	assert.Equal(t, len(requests[1]), 1)
+}
+
+func TestIsPolicySatisfied(t *testing.T) {
+	ok := &EndpointResult{}
+	failed := &EndpointResult{Err: &EndpointError{StatusCode: 500}}
+	skipped := &EndpointResult{Skipped: true}
+	assert.True(t, isPolicySatisfied(DeliverAll, nil))
+	assert.True(t, isPolicySatisfied(DeliverAny, nil))
+	assert.True(t, isPolicySatisfied(DeliverAll, []*EndpointResult{ok}))
+	assert.False(t, isPolicySatisfied(DeliverAll,
+		[]*EndpointResult{ok, failed}))
+	assert.True(t, isPolicySatisfied(DeliverFailover,
+		[]*EndpointResult{failed, ok, skipped}))
+	assert.False(t, isPolicySatisfied(DeliverFailover,
+		[]*EndpointResult{failed, skipped}))
+	assert.True(t, isPolicySatisfied(DeliverQuorum,
+		[]*EndpointResult{ok, ok, failed}))
+	assert.False(t, isPolicySatisfied(DeliverQuorum,
+		[]*EndpointResult{ok, failed}))
+}
@@ -0,0 +1,163 @@
+//    endpoints.go
+//    ~~~~~~~~~
+//    This module implements the named endpoint and publish tag features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The error matched via errors.Is when an endpoint is added with a name
+// that is already in use.
+var ErrEndpointExists = errors.New("endpoint already exists")
+
+// The error matched via errors.Is when no endpoint has the specified name.
+var ErrEndpointNotFound = errors.New("endpoint not found")
+
+// The error matched via errors.Is when a config entry has no 'control_uri'.
+var ErrInvalidEndpointConfig = errors.New("config entry has no control_uri")
+
+// An internal type used as the key of the publish tags context value.
+type publishTagsKey struct{}
+
+// Return a copy of the specified context that restricts the publishes made
+// with it to the endpoints that have at least one of the specified tags.
+// Endpoints without a matching tag are left out of the publish and of its
+// result.
+func WithPublishTags(ctx context.Context, tags ...string) context.Context {
+	return context.WithValue(ctx, publishTagsKey{},
+		append([]string(nil), tags...))
+}
+
+// An internal method for returning the publish tags of the specified
+// context.
+func publishTagsFromContext(ctx context.Context) []string {
+	tags, _ := ctx.Value(publishTagsKey{}).([]string)
+	return tags
+}
+
+// Create a client from the specified GRIP config entry and add it. The
+// 'name' of the entry must not be in use by another endpoint, in which case
+// ErrEndpointExists is returned. See ApplyGripConfig for the config format.
+func (gpc *GripPubControl) AddEndpoint(entry map[string]interface{}) error {
+	gpcc, err := newGripClientFromConfig(entry)
+	if err != nil {
+		return err
+	}
+	return gpc.putClient(gpcc, false, true)
+}
+
+// Create a client from the specified GRIP config entry and use it in place
+// of the endpoint with the same 'name'. ErrEndpointNotFound is returned if
+// there is no such endpoint.
+func (gpc *GripPubControl) ReplaceEndpoint(
+	entry map[string]interface{}) error {
+	gpcc, err := newGripClientFromConfig(entry)
+	if err != nil {
+		return err
+	}
+	return gpc.putClient(gpcc, true, false)
+}
+
+// Remove the endpoint with the specified name. ErrEndpointNotFound is
+// returned if there is no such endpoint.
+func (gpc *GripPubControl) RemoveEndpoint(name string) error {
+	gpc.clientsRWLock.Lock()
+	defer gpc.clientsRWLock.Unlock()
+	index := gpc.findClient(name)
+	if index < 0 {
+		return fmt.Errorf("%w: %q", ErrEndpointNotFound, name)
+	}
+	clients := make([]*GripPubControlClient, 0, len(gpc.clients)-1)
+	clients = append(clients, gpc.clients[:index]...)
+	gpc.clients = append(clients, gpc.clients[index+1:]...)
+	return nil
+}
+
+// Return the client of the endpoint with the specified name, or nil if
+// there is no such endpoint.
+func (gpc *GripPubControl) Endpoint(name string) *GripPubControlClient {
+	gpc.clientsRWLock.RLock()
+	defer gpc.clientsRWLock.RUnlock()
+	if index := gpc.findClient(name); index >= 0 {
+		return gpc.clients[index]
+	}
+	return nil
+}
+
+// An internal method for adding the specified client or using it in place
+// of the client with the same name, depending on whether adding and
+// replacing are allowed. Anonymous clients can only be added. The circuit
+// breaker settings of this GripPubControl instance are applied to the
+// client if SetCircuitBreaker has been called.
+func (gpc *GripPubControl) putClient(gpcc *GripPubControlClient,
+	replace, add bool) error {
+	gpc.applyCircuitBreaker(gpcc)
+	name := gpcc.Name()
+	gpc.clientsRWLock.Lock()
+	defer gpc.clientsRWLock.Unlock()
+	index := -1
+	if name != "" {
+		index = gpc.findClient(name)
+	}
+	if index >= 0 {
+		if !replace {
+			return fmt.Errorf("%w: %q", ErrEndpointExists, name)
+		}
+		// The slice is copied since publishes in progress may be using it.
+		clients := append([]*GripPubControlClient(nil), gpc.clients...)
+		clients[index] = gpcc
+		gpc.clients = clients
+		return nil
+	}
+	if !add {
+		return fmt.Errorf("%w: %q", ErrEndpointNotFound, name)
+	}
+	gpc.clients = append(gpc.clients, gpcc)
+	return nil
+}
+
+// An internal method for returning the index of the client with the
+// specified name, or -1 if there is no such client. The clients lock must
+// be held by the caller.
+func (gpc *GripPubControl) findClient(name string) int {
+	if name == "" {
+		return -1
+	}
+	for i, gpcc := range gpc.clients {
+		if gpcc.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// An internal method for parsing the tags of a config entry, which can be
+// either a list or a comma-separated string.
+func parseConfigTags(value interface{}) []string {
+	tags := make([]string, 0)
+	switch typed := value.(type) {
+	case []string:
+		tags = append(tags, typed...)
+	case []interface{}:
+		for _, tag := range typed {
+			if tag, ok := tag.(string); ok {
+				tags = append(tags, tag)
+			}
+		}
+	case string:
+		for _, tag := range strings.Split(typed, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
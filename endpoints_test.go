@@ -0,0 +1,177 @@
+//    endpoints_test.go
+//    ~~~~~~~~~
+//    This module implements the named endpoint and publish tag tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+)
+
+func TestApplyGripConfigNamesAndTags(t *testing.T) {
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": "http://eu", "name": "eu",
+			"tags": "eu, prod"},
+		map[string]interface{}{"control_uri": "http://us", "name": "us",
+			"tags": []interface{}{"us", "prod"}},
+		map[string]interface{}{"control_uri": "http://anon"}})
+	assert.Equal(t, len(gpc.clients), 3)
+	assert.Equal(t, gpc.Endpoint("eu").Uri(), "http://eu")
+	assert.Equal(t, gpc.Endpoint("eu").Tags(), []string{"eu", "prod"})
+	assert.Equal(t, gpc.Endpoint("us").Tags(), []string{"us", "prod"})
+	assert.Equal(t, gpc.clients[2].Name(), "")
+	assert.Nil(t, gpc.Endpoint("missing"))
+	gpc.ApplyGripConfig([]map[string]interface{}{
+		map[string]interface{}{"control_uri": "http://eu2", "name": "eu"}})
+	assert.Equal(t, len(gpc.clients), 3)
+	assert.Equal(t, gpc.clients[0].Uri(), "http://eu2")
+	logger, buffer := newTestLogger()
+	gpc.SetLogger(logger)
+	gpc.ApplyGripConfig([]map[string]interface{}{
+		map[string]interface{}{"contrl_uri": "http://typo", "name": "eu"}})
+	assert.Equal(t, gpc.clients[0].Uri(), "http://eu2")
+	skipped := buffer.find("skipping invalid config entry")
+	assert.Equal(t, len(skipped), 1)
+	assert.Equal(t, skipped[0]["level"], "WARN")
+	assert.Equal(t, skipped[0]["entry"], float64(0))
+	assert.Equal(t, skipped[0]["error"], ErrInvalidEndpointConfig.Error())
+}
+
+func TestAddGripClientAppends(t *testing.T) {
+	gpc := NewGripPubControl(nil)
+	first := NewGripPubControlClient("http://a")
+	first.SetName("a")
+	second := NewGripPubControlClient("http://a2")
+	second.SetName("a")
+	gpc.AddGripClient(first)
+	gpc.AddGripClient(second)
+	assert.Equal(t, gpc.clients, []*GripPubControlClient{first, second})
+}
+
+func TestAddReplaceRemoveEndpoint(t *testing.T) {
+	gpc := NewGripPubControl(nil)
+	gpc.SetCircuitBreaker(2, 0)
+	assert.True(t, errors.Is(gpc.AddEndpoint(map[string]interface{}{
+		"name": "a"}), ErrInvalidEndpointConfig))
+	assert.Nil(t, gpc.AddEndpoint(map[string]interface{}{
+		"control_uri": "http://a", "name": "a", "tags": []string{"x"}}))
+	assert.Equal(t, gpc.Endpoint("a").breaker.threshold, 2)
+	err := gpc.AddEndpoint(map[string]interface{}{
+		"control_uri": "http://a2", "name": "a"})
+	assert.True(t, errors.Is(err, ErrEndpointExists))
+	assert.Equal(t, err.Error(), `endpoint already exists: "a"`)
+	assert.Nil(t, gpc.AddEndpoint(map[string]interface{}{
+		"control_uri": "http://b", "name": "b"}))
+	err = gpc.ReplaceEndpoint(map[string]interface{}{
+		"control_uri": "http://c", "name": "c"})
+	assert.True(t, errors.Is(err, ErrEndpointNotFound))
+	clients := gpc.clients
+	assert.Nil(t, gpc.ReplaceEndpoint(map[string]interface{}{
+		"control_uri": "http://a2", "name": "a"}))
+	assert.Equal(t, clients[0].Uri(), "http://a")
+	assert.Equal(t, gpc.clients[0].Uri(), "http://a2")
+	assert.Empty(t, gpc.clients[0].Tags())
+	assert.True(t, errors.Is(gpc.RemoveEndpoint("c"), ErrEndpointNotFound))
+	assert.Nil(t, gpc.RemoveEndpoint("a"))
+	assert.Equal(t, len(gpc.clients), 1)
+	assert.Equal(t, gpc.clients[0].Name(), "b")
+	assert.Equal(t, len(clients), 2)
+}
+
+func TestWithPublishTags(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 200, 200)
+	gpc.clients[0].SetName("eu")
+	gpc.clients[0].SetTags("eu", "prod")
+	gpc.clients[1].SetTags("us", "prod")
+	result, err := gpc.PublishWithResult(WithPublishTags(
+		context.Background(), "eu"), "chan", newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(result.Endpoints), 1)
+	assert.Equal(t, result.Endpoints[0].Name, "eu")
+	assert.Equal(t, len(requests[0]), 1)
+	assert.Equal(t, len(requests[1]), 0)
+	assert.Equal(t, len(requests[2]), 0)
+	assert.Nil(t, gpc.PublishHttpStreamContext(WithPublishTags(
+		context.Background(), "staging", "prod"), "chan", "data", "", ""))
+	assert.Equal(t, len(requests[0]), 2)
+	assert.Equal(t, len(requests[1]), 1)
+	assert.Equal(t, len(requests[2]), 0)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, len(requests[2]), 1)
+}
+
+func TestWithPublishTagsRouted(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 200, 200, 200)
+	gpc.clients[0].SetTags("eu")
+	gpc.clients[1].SetTags("eu")
+	gpc.clients[2].SetTags("us")
+	gpc.clients[3].SetTags("us")
+	router := NewConsistentHashRouter(1)
+	gpc.SetRouter(router)
+	for i := 0; i < 20; i++ {
+		channel := "chan" + strconv.Itoa(i)
+		routed := router.Route(channel, gpc.clients)[0]
+		tagged := WithPublishTags(context.Background(), routed.Tags()...)
+		result, err := gpc.PublishWithResult(tagged, channel,
+			newTestItem("data"))
+		assert.Nil(t, err)
+		assert.Equal(t, len(result.Endpoints), 1)
+		assert.Equal(t, result.Endpoints[0].Uri, routed.Uri())
+		other := "eu"
+		if routed.HasTag("eu") {
+			other = "us"
+		}
+		result, err = gpc.PublishWithResult(WithPublishTags(
+			context.Background(), other), channel, newTestItem("data"))
+		assert.Nil(t, err)
+		assert.Empty(t, result.Endpoints)
+	}
+	total := 0
+	for _, reqs := range requests {
+		total += len(reqs)
+	}
+	assert.Equal(t, total, 20)
+}
+
+func TestPublishAsyncTags(t *testing.T) {
+	gpc, requests := newTestPolicyPubControl(t, 200, 200)
+	gpc.clients[0].SetTags("eu")
+	gpc.clients[1].SetTags("us")
+	gpc.StartAsync(nil)
+	defer gpc.Close()
+	assert.Nil(t, gpc.PublishAsyncContext(WithPublishTags(
+		context.Background(), "us"), "chan", newTestItem("data"), nil))
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Equal(t, len(requests[0]), 0)
+	assert.Equal(t, len(requests[1]), 1)
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		return next(WithPublishTags(ctx, "eu"), channel, item)
+	})
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"), nil))
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Equal(t, len(requests[0]), 1)
+	assert.Equal(t, len(requests[1]), 1)
+}
+
+func TestGripPubControlClientTags(t *testing.T) {
+	gpcc := NewGripPubControlClient("http://a")
+	assert.False(t, gpcc.HasTag("x"))
+	gpcc.SetTags("x", "y")
+	assert.True(t, gpcc.HasTag("z", "y"))
+	assert.False(t, gpcc.HasTag())
+	tags := gpcc.Tags()
+	tags[0] = "changed"
+	assert.Equal(t, gpcc.Tags(), []string{"x", "y"})
+	gpcc.SetName("a")
+	assert.Equal(t, gpcc.routingKey(), "a")
+	assert.Equal(t, gpcc.Health().Name, "a")
+}
@@ -0,0 +1,30 @@
+//    fastly.go
+//    ~~~~~~~~~
+//    This module implements the Fastly Fanout publishing features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+// The base URI of the Fastly API service endpoints that accept publish
+// requests for Fastly Fanout.
+const FastlyApiServiceUri = "https://api.fastly.com/service/"
+
+// Create a GRIP config entry for publishing to the Fastly Fanout service
+// with the specified service ID. The API token is sent in the Fastly-Key
+// header of each publish request.
+func FastlyGripConfig(serviceId, apiToken string) map[string]interface{} {
+	return map[string]interface{}{
+		"control_uri":         FastlyApiServiceUri + serviceId,
+		"control_auth_header": "Fastly-Key",
+		"key":                 apiToken}
+}
+
+// A convenience method for creating a GripPubControl instance that
+// publishes to the Fastly Fanout service with the specified service ID
+// and API token.
+func NewFastlyGripPubControl(serviceId, apiToken string) *GripPubControl {
+	return NewGripPubControl([]map[string]interface{}{
+		FastlyGripConfig(serviceId, apiToken)})
+}
@@ -0,0 +1,31 @@
+//    fastly_test.go
+//    ~~~~~~~~~
+//    This module implements the Fastly Fanout publishing tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFastlyGripConfig(t *testing.T) {
+	assert.Equal(t, FastlyGripConfig("service", "token"),
+		map[string]interface{}{
+			"control_uri":         "https://api.fastly.com/service/service",
+			"control_auth_header": "Fastly-Key",
+			"key":                 "token"})
+}
+
+func TestNewFastlyGripPubControl(t *testing.T) {
+	gpc := NewFastlyGripPubControl("service", "token")
+	assert.Equal(t, len(gpc.clients), 1)
+	gpcc := gpc.clients[0]
+	assert.Equal(t, gpcc.Uri(), "https://api.fastly.com/service/service")
+	headers, err := gpcc.generateHeaders()
+	assert.Nil(t, err)
+	assert.Equal(t, headers, map[string]string{"Fastly-Key": "token"})
+}
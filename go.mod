@@ -1,6 +1,6 @@
 module github.com/fanout/go-gripcontrol
 
-go 1.20
+go 1.21
 
 require (
 	github.com/fanout/go-pubcontrol v1.2.0
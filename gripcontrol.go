@@ -14,6 +14,9 @@ import "strings"
 import "github.com/golang-jwt/jwt"
 import "net/url"
 import "encoding/base64"
+import "io"
+import "log/slog"
+import "net/http"
 
 // The GripControl struct provides functionality that is used in conjunction
 // with GRIP proxies. This includes facilitating the creation of hold
@@ -54,6 +57,9 @@ func CreateHold(mode string, channels []*Channel, response interface{},
 	}
 	iresponse, err := getHoldResponse(response)
 	if err != nil {
+		getLogger().Debug("failed to create hold", slog.String("mode", mode),
+			channelLogAttr(getChannelNames(channels)),
+			slog.Any("error", err))
 		return "", err
 	}
 	instruct := make(map[string]interface{})
@@ -65,15 +71,30 @@ func CreateHold(mode string, channels []*Channel, response interface{},
 	if err != nil {
 		return "", err
 	}
+	getLogger().Debug("created hold", slog.String("mode", mode),
+		channelLogAttr(getChannelNames(channels)))
 	return string(message), nil
 }
 
 // Parse the specified GRIP URI into a config object that can then be passed
 // to the GripPubControl struct. The URI can include 'iss' and 'key' JWT
 // authentication query parameters as well as any other required query string
-// parameters. The JWT 'key' query parameter can be provided as-is or in base64
-// encoded format.
+// parameters. The JWT 'key' query parameter can be provided as-is, in base64
+// encoded format, or as a reference to a secret such as 'env:PUSHPIN_KEY' or
+// 'file:/run/secrets/grip-key' that is resolved via the SecretResolver
+// registered for that scheme. The 'auth-header' query parameter names a
+// header, such as 'Fastly-Key', in which the key is sent instead of being
+// used for JWT or bearer authentication, and each 'header-<name>' query
+// parameter adds a header to send with every publish request. Userinfo in
+// the URI is returned as 'control_user' and 'control_pass' for basic
+// authentication, where the password can also reference a secret. Any '/'
+// in the userinfo, such as in a 'file:' reference, must be percent-encoded
+// as '%2F', and an error is returned for userinfo that is not.
 func ParseGripUri(rawUri string) (map[string]interface{}, error) {
+	if hasUnencodedUserinfoSlash(rawUri) {
+		return nil, &GripFormatError{err: "userinfo of GRIP URI contains " +
+			"an unencoded '/', which must be percent-encoded as '%2F'"}
+	}
 	uri, err := url.Parse(rawUri)
 	if err != nil {
 		return nil, err
@@ -89,19 +110,34 @@ func ParseGripUri(rawUri string) (map[string]interface{}, error) {
 		key = params["key"][0]
 		delete(params, "key")
 	}
-	decodedKey := []byte(key)
-	if key != "" && key[:7] == "base64:" {
-		var err error
-		decodedKey, err = base64.StdEncoding.DecodeString(key[7:])
-		if err != nil {
-			return nil, err
+	authHeader := ""
+	if _, ok := params["auth-header"]; ok {
+		authHeader = params["auth-header"][0]
+		delete(params, "auth-header")
+	}
+	headers := make(map[string]string)
+	for param, values := range params {
+		if strings.HasPrefix(param, "header-") && len(param) > 7 {
+			headers[param[7:]] = values[0]
+			delete(params, param)
+		}
+	}
+	var password []byte
+	if uri.User != nil {
+		if rawPassword, ok := uri.User.Password(); ok {
+			password, err = ResolveSecret(rawPassword)
+			if err != nil {
+				return nil, err
+			}
 		}
 	}
+	decodedKey, err := ResolveSecret(key)
+	if err != nil {
+		return nil, err
+	}
 	qs := params.Encode()
 	path := uri.Path
-	if path[len(path)-1:] == "/" {
-		path = path[:len(path)-1]
-	}
+	path = strings.TrimSuffix(path, "/")
 	controlUri := uri.Scheme + "://" + uri.Host + path
 	if len(qs) > 0 {
 		controlUri += "?" + qs
@@ -114,6 +150,16 @@ func ParseGripUri(rawUri string) (map[string]interface{}, error) {
 	if len(decodedKey) > 0 {
 		out["key"] = decodedKey
 	}
+	if authHeader != "" {
+		out["control_auth_header"] = authHeader
+	}
+	if len(headers) > 0 {
+		out["control_headers"] = headers
+	}
+	if uri.User != nil && uri.User.Username() != "" {
+		out["control_user"] = uri.User.Username()
+		out["control_pass"] = string(password)
+	}
 	return out, nil
 }
 
@@ -126,8 +172,10 @@ func ValidateSig(token, key string) bool {
 		return []byte(key), nil
 	})
 	if err == nil && parsedToken.Valid {
+		getLogger().Debug("validated GRIP signature")
 		return true
 	}
+	getLogger().Debug("invalid GRIP signature", slog.Any("error", err))
 	return false
 }
 
@@ -152,6 +200,15 @@ func CreateGripChannelHeader(channels []*Channel) string {
 // instances when using the WebSocket-over-HTTP protocol. A RuntimeError
 // is raised if the format is invalid.
 func DecodeWebSocketEvents(body string) ([]*WebSocketEvent, error) {
+	return decodeWebSocketEvents(body, getLogger())
+}
+
+// An internal method for decoding the specified body into an array of
+// WebSocketEvent instances and recording the outcome with the specified
+// logger. Failures are only recorded at the debug level since the error is
+// returned to the caller.
+func decodeWebSocketEvents(body string,
+	logger *slog.Logger) ([]*WebSocketEvent, error) {
 	out := make([]*WebSocketEvent, 0)
 	for start := 0; start < utf8.RuneCountInString(body); {
 		partialBody := body[start:]
@@ -160,6 +217,9 @@ func DecodeWebSocketEvents(body string) ([]*WebSocketEvent, error) {
 		}
 		at := strings.Index(partialBody, "\r\n")
 		if at == -1 {
+			logger.Debug("failed to decode WebSocket events",
+				slog.Int("events", len(out)), slog.String("error",
+					"bad format"))
 			return nil, &GripFormatError{err: "bad format"}
 		}
 		start += at + 2
@@ -179,9 +239,38 @@ func DecodeWebSocketEvents(body string) ([]*WebSocketEvent, error) {
 		}
 		out = append(out, event)
 	}
+	logger.Debug("decoded WebSocket events", slog.Int("events", len(out)))
 	return out, nil
 }
 
+// Decode the WebSocket events in the body of the specified request made by
+// a GRIP proxy using the WebSocket-over-HTTP protocol. The connection ID
+// taken from the Connection-Id header is returned along with the events,
+// and is included in the log records. Failures are only recorded at the
+// debug level since the error is returned to the caller.
+func DecodeWebSocketRequest(r *http.Request) (string, []*WebSocketEvent,
+	error) {
+	connectionId := r.Header.Get("Connection-Id")
+	logger := getLogger().With(slog.String(LogKeyConnectionId,
+		connectionId))
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		logger.Debug("failed to read WebSocket events",
+			slog.Any("error", err))
+		return connectionId, nil, err
+	}
+	events, err := decodeWebSocketEvents(string(body), logger)
+	if err != nil {
+		return connectionId, nil, err
+	}
+	for _, event := range events {
+		logger.Debug("received WebSocket event",
+			slog.String("type", event.Type),
+			slog.Int("bytes", len(event.Content)))
+	}
+	return connectionId, events, nil
+}
+
 // Encode the specified array of WebSocketEvent instances. The returned string
 // value should then be passed to a GRIP proxy in the body of an HTTP response
 // when using the WebSocket-over-HTTP protocol.
@@ -195,6 +284,8 @@ func EncodeWebSocketEvents(events []*WebSocketEvent) string {
 			out += fmt.Sprintf("%s\r\n", event.Type)
 		}
 	}
+	getLogger().Debug("encoded WebSocket events", slog.Int("events",
+		len(events)))
 	return out
 }
 
@@ -212,9 +303,25 @@ func WebSocketControlMessage(messageType string,
 	}
 	out["type"] = messageType
 	message, err := json.Marshal(out)
+	if err != nil {
+		getLogger().Debug("failed to create WebSocket control message",
+			slog.String("type", messageType), slog.Any("error", err))
+	} else {
+		getLogger().Debug("created WebSocket control message",
+			slog.String("type", messageType))
+	}
 	return string(message), err
 }
 
+// An internal method used to get the names of the specified channels.
+func getChannelNames(channels []*Channel) []string {
+	names := make([]string, 0, len(channels))
+	for _, channel := range channels {
+		names = append(names, channel.Name)
+	}
+	return names
+}
+
 // An internal method used to get a channel map used for GRIP holds. The
 // resulting map is used for creating GRIP proxy hold instructions.
 func getHoldChannels(channels []*Channel) []map[string]string {
@@ -270,6 +377,40 @@ func getHoldResponse(response interface{}) (map[string]interface{}, error) {
 	return iresponse, nil
 }
 
+// An internal method for determining whether the userinfo of the
+// specified URI contains an unencoded '/', in which case the authority ends
+// at that '/' and the rest of the userinfo and the host become the path.
+// This is detected by an '@' in the path combined with an authority whose
+// port is missing or not numeric.
+func hasUnencodedUserinfoSlash(rawUri string) bool {
+	_, rest, ok := strings.Cut(rawUri, "://")
+	if !ok {
+		return false
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	authority, path, ok := strings.Cut(rest, "/")
+	if !ok || strings.Contains(authority, "@") ||
+		!strings.Contains(path, "@") {
+		return false
+	}
+	colon := strings.LastIndex(authority, ":")
+	if colon < 0 || strings.HasSuffix(authority, "]") {
+		return false
+	}
+	port := authority[colon+1:]
+	if port == "" {
+		return true
+	}
+	for _, c := range port {
+		if c < '0' || c > '9' {
+			return true
+		}
+	}
+	return false
+}
+
 // An error object used to represent a GRIP formatting error.
 type GripFormatError struct {
 	err string
@@ -12,6 +12,9 @@ import (
 	"encoding/json"
 	"github.com/golang-jwt/jwt"
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 )
@@ -96,6 +99,67 @@ func TestParseGripUri(t *testing.T) {
 	config, err = ParseGripUri(uri)
 	assert.Nil(t, err)
 	assert.Equal(t, config["key"], []byte("geag121321=="))
+	t.Setenv("GRIPCONTROL_TEST_KEY", "base64:geag121321==")
+	config, err = ParseGripUri("http://api.fanout.io/realm/realm?iss=realm" +
+		"&key=env:GRIPCONTROL_TEST_KEY")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_uri"], "http://api.fanout.io/realm/realm")
+	assert.Equal(t, config["key"], key)
+	config, err = ParseGripUri("http://api.fanout.io/realm/realm?iss=realm" +
+		"&key=env:GRIPCONTROL_TEST_MISSING")
+	assert.Nil(t, config)
+	assert.NotNil(t, err)
+	config, err = ParseGripUri("http://api.fanout.io/realm/realm?key=abc")
+	assert.Nil(t, err)
+	assert.Equal(t, config["key"], []byte("abc"))
+	config, err = ParseGripUri("https://api.fastly.com/service/service" +
+		"?key=token&auth-header=Fastly-Key")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_uri"],
+		"https://api.fastly.com/service/service")
+	assert.Equal(t, config["control_auth_header"], "Fastly-Key")
+	assert.Equal(t, config["key"], []byte("token"))
+	_, ok := config["control_user"]
+	assert.False(t, ok)
+	t.Setenv("GRIPCONTROL_TEST_PASS", "pass")
+	config, err = ParseGripUri("https://user:env:GRIPCONTROL_TEST_PASS@" +
+		"pushpin:5561/?header-X-Gateway=gw&header-X-Other=other&param=1")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_uri"], "https://pushpin:5561?param=1")
+	assert.Equal(t, config["control_user"], "user")
+	assert.Equal(t, config["control_pass"], "pass")
+	assert.Equal(t, config["control_headers"], map[string]string{
+		"X-Gateway": "gw", "X-Other": "other"})
+	config, err = ParseGripUri("https://user@pushpin:5561/")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_user"], "user")
+	assert.Equal(t, config["control_pass"], "")
+	config, err = ParseGripUri("https://user:env:GRIPCONTROL_TEST_MISSING" +
+		"@pushpin:5561/")
+	assert.Nil(t, config)
+	assert.NotNil(t, err)
+}
+
+func TestParseGripUriFileSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pw")
+	assert.Nil(t, os.WriteFile(path, []byte("pass\n"), 0600))
+	config, err := ParseGripUri("https://user:file:" +
+		strings.ReplaceAll(path, "/", "%2F") + "@pushpin:5561/")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_uri"], "https://pushpin:5561")
+	assert.Equal(t, config["control_user"], "user")
+	assert.Equal(t, config["control_pass"], "pass")
+	for _, uri := range []string{"https://user:file:" + path +
+		"@pushpin:5561/", "https://file:" + path + "@pushpin/",
+		"https://user:file:" + path + "@pushpin/realm?iss=realm"} {
+		config, err = ParseGripUri(uri)
+		assert.Nil(t, config)
+		assert.NotNil(t, err)
+		assert.Contains(t, err.Error(), "%2F")
+	}
+	config, err = ParseGripUri("https://pushpin:5561/realm/a@b")
+	assert.Nil(t, err)
+	assert.Equal(t, config["control_uri"], "https://pushpin:5561/realm/a@b")
 }
 
 func doesKeyExist(obj map[string]interface{}, key string) bool {
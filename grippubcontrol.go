@@ -7,58 +7,466 @@
 
 package gripcontrol
 
-import "github.com/fanout/go-pubcontrol"
+import (
+	"context"
+	"fmt"
+	"github.com/fanout/go-pubcontrol"
+	"log/slog"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
 
 // The GripPubControl struct allows consumers to easily publish HTTP response
 // and HTTP stream format messages to GRIP proxies. Configuring GripPubControl
 // is slightly different from configuring PubControl in that the 'uri' and
 // 'iss' keys in each config entry should have a 'control_' prefix.
-// GripPubControl inherits from PubControl and therefore also provides all
-// of the same functionality.
+// GripPubControl provides the same methods as PubControl. Each configured
+// endpoint is represented by a GripPubControlClient instance, and
+// PubControlClient instances added via AddClient are wrapped accordingly.
 type GripPubControl struct {
-	*pubcontrol.PubControl
+	clients        []*GripPubControlClient
+	clientsRWLock  sync.RWMutex
+	batchSize      int
+	deliveryPolicy DeliveryPolicy
+	router         ChannelRouter
+	sequencer      *Sequencer
+	history        *History
+	interceptors   []Interceptor
+	observer       PublishObserver
+	logger         atomic.Pointer[slog.Logger]
+	async          *asyncPublisher
+	outbox         *outbox
+	settingsRWLock sync.RWMutex
+
+	circuitThreshold     int
+	circuitProbeInterval time.Duration
 }
 
 // Initialize with or without a configuration. A configuration can be applied
 // after initialization via the apply_grip_config method.
 func NewGripPubControl(config []map[string]interface{}) *GripPubControl {
-	gripPubControl := &GripPubControl{pubcontrol.NewPubControl(nil)}
+	gripPubControl := &GripPubControl{}
+	gripPubControl.clients = make([]*GripPubControlClient, 0)
+	gripPubControl.batchSize = DefaultBatchSize
 	if config != nil && len(config) > 0 {
 		gripPubControl.ApplyGripConfig(config)
 	}
 	return gripPubControl
 }
 
+// Remove all of the configured client instances.
+func (gpc *GripPubControl) RemoveAllClients() {
+	gpc.clientsRWLock.Lock()
+	defer gpc.clientsRWLock.Unlock()
+	gpc.clients = make([]*GripPubControlClient, 0)
+}
+
+// Add the specified PubControlClient instance.
+func (gpc *GripPubControl) AddClient(pcc *pubcontrol.PubControlClient) {
+	gpc.AddGripClient(wrapPubControlClient(pcc))
+}
+
+// Add the specified GripPubControlClient instance. The client is always
+// added, even if a client with the same name has already been added; use
+// AddEndpoint or ReplaceEndpoint to manage named endpoints. The circuit
+// breaker settings of this GripPubControl instance are applied to the
+// client if SetCircuitBreaker has been called.
+func (gpc *GripPubControl) AddGripClient(gpcc *GripPubControlClient) {
+	gpc.applyCircuitBreaker(gpcc)
+	gpc.clientsRWLock.Lock()
+	defer gpc.clientsRWLock.Unlock()
+	gpc.clients = append(gpc.clients, gpcc)
+}
+
+// Apply the specified PubControl configuration to this GripPubControl
+// instance. The 'uri' and 'iss' keys of each entry are treated as their
+// 'control_' prefixed equivalents.
+func (gpc *GripPubControl) ApplyConfig(config []map[string]interface{}) {
+	gripConfig := make([]map[string]interface{}, 0, len(config))
+	for _, entry := range config {
+		gripEntry := make(map[string]interface{})
+		for key, value := range entry {
+			if key == "uri" || key == "iss" {
+				key = "control_" + key
+			}
+			gripEntry[key] = value
+		}
+		gripConfig = append(gripConfig, gripEntry)
+	}
+	gpc.ApplyGripConfig(gripConfig)
+}
+
 // Apply the specified GRIP configuration to this GripPubControl instance.
 // The configuration object can either be a hash or an array of hashes where
-// each hash corresponds to a single PubControlClient instance. Each hash
-// will be parsed and a PubControlClient will be created either using just
-// a URI or a URI and JWT authentication information.
+// each hash corresponds to a single GripPubControlClient instance. Each hash
+// will be parsed and a GripPubControlClient will be created either using just
+// a URI or a URI and JWT authentication information. If 'control_auth_header'
+// is set then the 'key' value is sent in that header rather than being used
+// for JWT or bearer authentication, and any 'control_headers' are sent with
+// every publish request. Basic authentication is used if 'control_user' and
+// 'control_pass' are set. An entry can also have a 'name', in which case it
+// replaces the endpoint with the same name if there is one, and 'tags' as
+// either a list or a comma-separated string. Invalid entries, such as those
+// without a 'control_uri', are skipped and logged as warnings.
 func (gpc *GripPubControl) ApplyGripConfig(config []map[string]interface{}) {
-	for _, entry := range config {
-		if _, ok := entry["control_uri"]; !ok {
+	for i, entry := range config {
+		gpcc, err := newGripClientFromConfig(entry)
+		if err != nil {
+			gpc.getLogger().Warn("skipping invalid config entry",
+				slog.Int("entry", i), slog.Any("error", err))
 			continue
 		}
-		pcc := pubcontrol.NewPubControlClient(entry["control_uri"].(string))
-		if _, ok := entry["control_iss"]; ok {
-			claim := make(map[string]interface{})
-			claim["iss"] = entry["control_iss"]
-			switch entry["key"].(type) {
-			case string:
-				pcc.SetAuthJwt(claim, []byte(entry["key"].(string)))
-			case []byte:
-				pcc.SetAuthJwt(claim, entry["key"].([]byte))
+		gpc.putClient(gpcc, true, true)
+	}
+}
+
+// An internal method for creating a GripPubControlClient from the specified
+// GRIP config entry.
+func newGripClientFromConfig(
+	entry map[string]interface{}) (*GripPubControlClient, error) {
+	uri, ok := entry["control_uri"].(string)
+	if !ok {
+		return nil, ErrInvalidEndpointConfig
+	}
+	gpcc := NewGripPubControlClient(uri)
+	if name, ok := entry["name"].(string); ok {
+		gpcc.SetName(name)
+	}
+	if tags, ok := entry["tags"]; ok {
+		gpcc.SetTags(parseConfigTags(tags)...)
+	}
+	if header, ok := entry["control_auth_header"].(string); ok {
+		switch entry["key"].(type) {
+		case string:
+			gpcc.SetHeader(header, entry["key"].(string))
+		case []byte:
+			gpcc.SetHeader(header, string(entry["key"].([]byte)))
+		}
+	} else if _, ok := entry["control_iss"]; ok {
+		claim := make(map[string]interface{})
+		claim["iss"] = entry["control_iss"]
+		switch entry["key"].(type) {
+		case string:
+			gpcc.SetAuthJwt(claim, []byte(entry["key"].(string)))
+		case []byte:
+			gpcc.SetAuthJwt(claim, entry["key"].([]byte))
+		}
+	} else if _, ok := entry["key"]; ok {
+		switch entry["key"].(type) {
+		case string:
+			gpcc.SetAuthBearer(entry["key"].(string))
+		case []byte:
+			gpcc.SetAuthBearer(string(entry["key"].([]byte)))
+		}
+	}
+	if user, ok := entry["control_user"].(string); ok {
+		pass, _ := entry["control_pass"].(string)
+		gpcc.SetAuthBasic(user, pass)
+	}
+	if headers, ok := entry["control_headers"].(map[string]string); ok {
+		for name, value := range headers {
+			gpcc.SetHeader(name, value)
+		}
+	}
+	return gpcc, nil
+}
+
+// The publish method for publishing the specified item to the specified
+// channel on the configured endpoints. Different endpoints are published
+// to in parallel, with this function waiting for them to finish. Any errors
+// (including panics) are aggregated into one error, which is only returned
+// if the delivery policy was not satisfied.
+func (gpc *GripPubControl) Publish(channel string,
+	item *pubcontrol.Item) error {
+	return gpc.PublishContext(context.Background(), channel, item)
+}
+
+// The same as Publish except that the publish requests are canceled when
+// the specified context is canceled or its deadline is exceeded.
+func (gpc *GripPubControl) PublishContext(ctx context.Context,
+	channel string, item *pubcontrol.Item) error {
+	return gpc.publishItems(ctx, []*ChannelItem{
+		&ChannelItem{Channel: channel, Item: item}}, "channel: "+channel)
+}
+
+// The same as PublishContext except that the outcome of publishing to each
+// endpoint is returned as well. The result is returned even if an error
+// occurred unless the item could not be exported.
+func (gpc *GripPubControl) PublishWithResult(ctx context.Context,
+	channel string, item *pubcontrol.Item) (*PublishResult, error) {
+	return gpc.publishItemsWithResult(ctx, []*ChannelItem{
+		&ChannelItem{Channel: channel, Item: item}}, "channel: "+channel)
+}
+
+// An internal method for publishing the specified items to all of the
+// configured clients in parallel. The target is used to describe the
+// items in the aggregated error.
+func (gpc *GripPubControl) publishItems(ctx context.Context,
+	items []*ChannelItem, target string) error {
+	_, err := gpc.publishItemsWithResult(ctx, items, target)
+	return err
+}
+
+// An internal method for publishing the specified items to all of the
+// configured clients in parallel and returning the outcome for each. The
+// items first pass through the interceptor chain if there is one.
+func (gpc *GripPubControl) publishItemsWithResult(ctx context.Context,
+	items []*ChannelItem, target string) (*PublishResult, error) {
+	if interceptors := gpc.getInterceptors(); len(interceptors) > 0 {
+		result, _, errs := interceptItems(ctx, interceptors, items,
+			func(ctx context.Context, items []*ChannelItem,
+				ctxs []context.Context) (*PublishResult, error) {
+				return gpc.sendItems(ctx, items, ctxs, target)
+			})
+		return result, joinInterceptedErrors(errs)
+	}
+	return gpc.sendItems(ctx, items, nil, target)
+}
+
+// An internal method for sending the specified items to all of the
+// configured clients in parallel and returning the outcome for each. The
+// items are exported once and the exports are shared by all of the
+// clients. The item meta of each item is taken from the context at the
+// same index of ctxs, or from ctx if ctxs is nil.
+func (gpc *GripPubControl) sendItems(ctx context.Context,
+	items []*ChannelItem, ctxs []context.Context,
+	target string) (*PublishResult, error) {
+	exports, err := exportChannelItems(items)
+	if err != nil {
+		return nil, err
+	}
+	result, _, err := gpc.sendExports(ctx, gpc.getDeliveryPolicy(), items,
+		exports, ctxs, target)
+	return result, err
+}
+
+// An internal method for sending the specified items, which have already
+// been exported to the specified exports, to all of the configured clients
+// in parallel according to the specified delivery policy. The outcome for
+// each client is returned along with the targets that were published to,
+// whose result is at the same index of the endpoint results.
+func (gpc *GripPubControl) sendExports(ctx context.Context,
+	policy DeliveryPolicy, items []*ChannelItem,
+	exports []map[string]interface{}, ctxs []context.Context,
+	target string) (*PublishResult, []*publishTarget, error) {
+	for i, export := range exports {
+		if ctxs != nil {
+			applyItemMeta(ctxs[i], export)
+		} else {
+			applyItemMeta(ctx, export)
+		}
+	}
+	reservation, err := gpc.sequenceExports(ctx, exports)
+	if err != nil {
+		return nil, nil, err
+	}
+	targets := gpc.getPublishTargets(ctx, items, exports)
+	results := deliverToTargets(ctx, policy, targets)
+	accepted := acceptedChannels(targets, results)
+	gpc.recordExports(ctx, exports, accepted)
+	reservation.release(accepted)
+	err = aggregatePublishErrors(policy, results, target)
+	if err != nil {
+		gpc.getLogger().DebugContext(ctx, "publish failed",
+			channelLogAttr(channelItemNames(items)), slog.Any("error", err))
+	}
+	return &PublishResult{Endpoints: results}, targets, err
+}
+
+// An internal method for returning the distinct channels of the specified
+// items in order.
+func channelItemNames(items []*ChannelItem) []string {
+	channels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, item := range items {
+		if !seen[item.Channel] {
+			seen[item.Channel] = true
+			channels = append(channels, item.Channel)
+		}
+	}
+	return channels
+}
+
+// An internal struct representing the items that are to be published to
+// a single client, along with the observer to notify and the logger to
+// record the outcome with.
+type publishTarget struct {
+	client   *GripPubControlClient
+	items    []*ChannelItem
+	exports  []map[string]interface{}
+	observer PublishObserver
+	logger   *slog.Logger
+}
+
+// An internal method for determining which of the configured clients the
+// specified exported items are published to. Every client receives every
+// item unless a router is set, in which case each client only receives the
+// items for the channels routed to it and clients without items are left
+// out. Channels are routed over all of the configured clients, and only
+// then are the clients without one of the publish tags of the specified
+// context left out if any are set, so that tagged publishes reach the same
+// shards as untagged ones.
+func (gpc *GripPubControl) getPublishTargets(ctx context.Context,
+	items []*ChannelItem,
+	exports []map[string]interface{}) []*publishTarget {
+	gpc.clientsRWLock.RLock()
+	clients := gpc.clients
+	gpc.clientsRWLock.RUnlock()
+	tags := publishTagsFromContext(ctx)
+	router := gpc.getRouter()
+	observer := gpc.getObserver()
+	logger := gpc.getLogger()
+	targets := make([]*publishTarget, 0, len(clients))
+	if router == nil {
+		for _, client := range filterTaggedClients(clients, tags) {
+			targets = append(targets, &publishTarget{client: client,
+				items: items, exports: exports, observer: observer,
+				logger: logger})
+		}
+		return targets
+	}
+	byClient := make(map[*GripPubControlClient]*publishTarget)
+	routes := make(map[string][]*GripPubControlClient)
+	for i, item := range items {
+		routed, ok := routes[item.Channel]
+		if !ok {
+			routed = filterTaggedClients(router.Route(item.Channel, clients),
+				tags)
+			routes[item.Channel] = routed
+		}
+		for _, client := range routed {
+			target, ok := byClient[client]
+			if !ok {
+				target = &publishTarget{client: client, observer: observer,
+					logger: logger}
+				byClient[client] = target
+				targets = append(targets, target)
 			}
-		} else if _, ok := entry["key"]; ok {
-			switch entry["key"].(type) {
-			case string:
-				pcc.SetAuthBearer(entry["key"].(string))
-			case []byte:
-				pcc.SetAuthBearer(string(entry["key"].([]byte)))
+			if len(target.items) > 0 &&
+				target.items[len(target.items)-1] == item {
+				continue
 			}
+			target.items = append(target.items, item)
+			target.exports = append(target.exports, exports[i])
 		}
-		gpc.AddClient(pcc)
 	}
+	return targets
+}
+
+// An internal method for returning the specified clients that have one of
+// the specified tags, or all of the clients if no tags are specified.
+func filterTaggedClients(clients []*GripPubControlClient,
+	tags []string) []*GripPubControlClient {
+	if len(tags) == 0 {
+		return clients
+	}
+	tagged := make([]*GripPubControlClient, 0, len(clients))
+	for _, client := range clients {
+		if client.HasTag(tags...) {
+			tagged = append(tagged, client)
+		}
+	}
+	return tagged
+}
+
+// An internal method for publishing the exports of the target to its
+// client, notifying the observer of the target if there is one and logging
+// the outcome.
+func (target *publishTarget) publish(ctx context.Context) *EndpointResult {
+	logged := target.logger.Enabled(ctx, slog.LevelWarn)
+	if target.observer == nil && !logged {
+		return target.client.publishExports(ctx, target.exports)
+	}
+	channels := target.channels()
+	var event *PublishEvent
+	if target.observer != nil {
+		event = &PublishEvent{Uri: target.client.uri,
+			Name: target.client.Name(), Channels: channels,
+			Items: len(target.exports)}
+		ctx = target.observer.PublishStarted(ctx, event)
+	}
+	result := target.client.publishExports(ctx, target.exports)
+	if event != nil {
+		event.Bytes = result.Bytes
+		event.StatusCode = result.StatusCode
+		event.Duration = result.Latency
+		event.Err = result.Err
+		target.observer.PublishFinished(ctx, event)
+	}
+	attrs := append(endpointLogAttrs(target.client),
+		channelLogAttr(channels), slog.Int("items", len(target.exports)),
+		slog.Int("status", result.StatusCode),
+		slog.Duration("duration", result.Latency))
+	if result.Err != nil {
+		target.logger.WarnContext(ctx, "publish to endpoint failed",
+			append(attrs, slog.Any("error", result.Err))...)
+	} else {
+		target.logger.DebugContext(ctx, "published to endpoint",
+			append(attrs, slog.Int("bytes", result.Bytes))...)
+	}
+	return result
+}
+
+// An internal method for returning the channels of the exports that at
+// least one of the specified targets accepted, whose results are at the
+// same index of the specified results.
+func acceptedChannels(targets []*publishTarget,
+	results []*EndpointResult) map[string]bool {
+	accepted := make(map[string]bool)
+	for i, target := range targets {
+		if results[i].Err == nil && !results[i].Skipped {
+			for _, channel := range target.channels() {
+				accepted[channel] = true
+			}
+		}
+	}
+	return accepted
+}
+
+// An internal method for returning the distinct channels of the exports of
+// the target in order.
+func (target *publishTarget) channels() []string {
+	channels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, export := range target.exports {
+		channel, _ := export["channel"].(string)
+		if !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// An internal method for publishing to the specified targets in parallel
+// and waiting for them to finish. The returned slice holds the result for
+// the target at the same index. Panics are recovered and reported as
+// errors.
+func publishToTargets(ctx context.Context,
+	targets []*publishTarget) []*EndpointResult {
+	results := make([]*EndpointResult, len(targets))
+	wg := sync.WaitGroup{}
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target *publishTarget) {
+			defer func() {
+				if err := recover(); err != nil {
+					stack := make([]byte, 1024*8)
+					stack = stack[:runtime.Stack(stack, false)]
+					results[i] = &EndpointResult{Uri: target.client.uri,
+						Err: &EndpointError{Uri: target.client.uri,
+							Err: &GripPublishError{err: fmt.Sprintf(
+								"PANIC: %v\n%s", err, stack)}}}
+				}
+				wg.Done()
+			}()
+			results[i] = target.publish(ctx)
+		}(i, target)
+	}
+	wg.Wait()
+	return results
 }
 
 // Publish an HTTP response format message to all of the configured
@@ -69,11 +477,20 @@ func (gpc *GripPubControl) ApplyGripConfig(config []map[string]interface{}) {
 // be created and have the 'body' field set to the specified value).
 func (gpc *GripPubControl) PublishHttpResponse(channel string,
 	http_response interface{}, id, prevId string) error {
+	return gpc.PublishHttpResponseContext(context.Background(), channel,
+		http_response, id, prevId)
+}
+
+// The same as PublishHttpResponse except that the publish requests are
+// canceled when the specified context is canceled or its deadline is
+// exceeded.
+func (gpc *GripPubControl) PublishHttpResponseContext(ctx context.Context,
+	channel string, http_response interface{}, id, prevId string) error {
 	item, err := getHttpResponseItem(http_response, id, prevId)
 	if err != nil {
 		return err
 	}
-	return gpc.Publish(channel, item)
+	return gpc.PublishContext(ctx, channel, item)
 }
 
 // Publish an HTTP stream format message to all of the configured
@@ -84,11 +501,129 @@ func (gpc *GripPubControl) PublishHttpResponse(channel string,
 // be created and have the 'content' field set to the specified value).
 func (gpc *GripPubControl) PublishHttpStream(channel string,
 	http_stream interface{}, id, prevId string) error {
+	return gpc.PublishHttpStreamContext(context.Background(), channel,
+		http_stream, id, prevId)
+}
+
+// The same as PublishHttpStream except that the publish requests are
+// canceled when the specified context is canceled or its deadline is
+// exceeded.
+func (gpc *GripPubControl) PublishHttpStreamContext(ctx context.Context,
+	channel string, http_stream interface{}, id, prevId string) error {
 	item, err := getHttpStreamItem(http_stream, id, prevId)
 	if err != nil {
 		return err
 	}
-	return gpc.Publish(channel, item)
+	return gpc.PublishContext(ctx, channel, item)
+}
+
+// Publish an HTTP response hint to all of the configured PubControlClients
+// with a specified channel and optional ID and previous ID. A hint causes
+// the GRIP proxy to retry the held requests against the origin, which is
+// useful when the content is too large to publish or must be fetched
+// reliably.
+func (gpc *GripPubControl) PublishHttpResponseHint(channel string, id,
+	prevId string) error {
+	return gpc.PublishHttpResponseHintContext(context.Background(), channel,
+		id, prevId)
+}
+
+// The same as PublishHttpResponseHint except that the publish requests are
+// canceled when the specified context is canceled or its deadline is
+// exceeded.
+func (gpc *GripPubControl) PublishHttpResponseHintContext(
+	ctx context.Context, channel string, id, prevId string) error {
+	return gpc.PublishHttpResponseContext(ctx, channel,
+		&HttpResponseFormat{Hint: true}, id, prevId)
+}
+
+// Publish an HTTP stream hint to all of the configured PubControlClients
+// with a specified channel and optional ID and previous ID. A hint causes
+// the GRIP proxy to fetch the content for the streams from the origin.
+func (gpc *GripPubControl) PublishHttpStreamHint(channel string, id,
+	prevId string) error {
+	return gpc.PublishHttpStreamHintContext(context.Background(), channel,
+		id, prevId)
+}
+
+// The same as PublishHttpStreamHint except that the publish requests are
+// canceled when the specified context is canceled or its deadline is
+// exceeded.
+func (gpc *GripPubControl) PublishHttpStreamHintContext(ctx context.Context,
+	channel string, id, prevId string) error {
+	return gpc.PublishHttpStreamContext(ctx, channel,
+		&HttpStreamFormat{Hint: true}, id, prevId)
+}
+
+// Publish a WebSocket message format message to all of the configured
+// PubControlClients with a specified channel, message, and optional ID and
+// previous ID. Note that the 'ws_message' parameter can be provided as
+// either a WebSocketMessageFormat instance or a string / byte array (in
+// which case a WebSocketMessageFormat instance will automatically be created
+// and have the 'content' field set to the specified value). Content that is
+// not valid UTF-8 is published as a binary message.
+func (gpc *GripPubControl) PublishWebSocketMessage(channel string,
+	ws_message interface{}, id, prevId string) error {
+	return gpc.PublishWebSocketMessageContext(context.Background(), channel,
+		ws_message, id, prevId)
+}
+
+// The same as PublishWebSocketMessage except that the publish requests are
+// canceled when the specified context is canceled or its deadline is
+// exceeded.
+func (gpc *GripPubControl) PublishWebSocketMessageContext(
+	ctx context.Context, channel string, ws_message interface{}, id,
+	prevId string) error {
+	item, err := getWebSocketMessageItem(ws_message, id, prevId)
+	if err != nil {
+		return err
+	}
+	return gpc.PublishContext(ctx, channel, item)
+}
+
+// Publish a single item containing all of the specified formats to all of
+// the configured PubControlClients with a specified channel and optional ID
+// and previous ID. This allows HTTP response, HTTP stream and WebSocket
+// subscribers to be notified of the same event in one publish that shares
+// one ID sequence. Each type of format may only be specified once.
+func (gpc *GripPubControl) PublishFormats(channel string,
+	formats []pubcontrol.Formatter, id, prevId string) error {
+	return gpc.PublishFormatsContext(context.Background(), channel, formats,
+		id, prevId)
+}
+
+// The same as PublishFormats except that the publish requests are canceled
+// when the specified context is canceled or its deadline is exceeded.
+func (gpc *GripPubControl) PublishFormatsContext(ctx context.Context,
+	channel string, formats []pubcontrol.Formatter, id, prevId string) error {
+	item, err := getFormatsItem(formats, id, prevId)
+	if err != nil {
+		return err
+	}
+	return gpc.PublishContext(ctx, channel, item)
+}
+
+// An internal method for returning an Item instance containing the
+// specified formats. An error is returned if no formats are specified or
+// if a type of format is specified more than once.
+func getFormatsItem(formats []pubcontrol.Formatter, id,
+	prevId string) (*pubcontrol.Item, error) {
+	if len(formats) == 0 {
+		return nil, &GripPublishError{err: "at least one format must be " +
+			"specified"}
+	}
+	names := make(map[string]bool)
+	for _, format := range formats {
+		if format == nil {
+			return nil, &GripPublishError{err: "formats must not be nil"}
+		}
+		if names[format.Name()] {
+			return nil, &GripPublishError{err: "only one " + format.Name() +
+				" format can be specified"}
+		}
+		names[format.Name()] = true
+	}
+	return pubcontrol.NewItem(formats, id, prevId), nil
 }
 
 // An internal method for returning an Item instance used for HTTP response
@@ -129,9 +664,33 @@ func getHttpStreamItem(http_stream interface{}, id,
 	return pubcontrol.NewItem([]pubcontrol.Formatter{format}, id, prevId), nil
 }
 
+// An internal method for returning an Item instance used for WebSocket
+// message publishing based on the specified parameters.
+func getWebSocketMessageItem(ws_message interface{}, id,
+	prevId string) (*pubcontrol.Item, error) {
+	var format *WebSocketMessageFormat
+	switch ws_message.(type) {
+	case *WebSocketMessageFormat:
+		format = ws_message.(*WebSocketMessageFormat)
+	case string:
+		format = &WebSocketMessageFormat{Content: []byte(ws_message.(string))}
+	case []byte:
+		format = &WebSocketMessageFormat{Content: ws_message.([]byte)}
+	default:
+		return nil, &GripPublishError{err: "ws_message parameter must be of " +
+			"type *WebSocketMessageFormat, string, or []byte"}
+	}
+	return pubcontrol.NewItem([]pubcontrol.Formatter{format}, id, prevId), nil
+}
+
 // An error object representing an error encountered during publishing.
+// When publishing to one or more endpoints failed, the error unwraps to the
+// EndpointError of each failed endpoint and the outcome for every endpoint
+// is available via the Result method.
 type GripPublishError struct {
-	err string
+	err    string
+	errs   []error
+	result *PublishResult
 }
 
 // The function used to retrieve the message associated with a
@@ -139,3 +698,14 @@ type GripPublishError struct {
 func (e GripPublishError) Error() string {
 	return e.err
 }
+
+// Return the errors of the endpoints that failed.
+func (e GripPublishError) Unwrap() []error {
+	return e.errs
+}
+
+// Return the outcome of publishing to each endpoint, or nil if the error
+// did not occur while publishing to endpoints.
+func (e GripPublishError) Result() *PublishResult {
+	return e.result
+}
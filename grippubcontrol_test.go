@@ -8,8 +8,10 @@
 package gripcontrol
 
 import (
+	"context"
 	"github.com/fanout/go-pubcontrol"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -33,6 +35,61 @@ func TestApplyGripConfig(t *testing.T) {
 			"key":         "key"}})
 }
 
+func TestApplyGripConfigHeaders(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.ApplyGripConfig([]map[string]interface{}{
+		map[string]interface{}{
+			"control_uri":         server.URL,
+			"control_iss":         "hello",
+			"control_auth_header": "Fastly-Key",
+			"control_headers":     map[string]string{"X-Header": "value"},
+			"key":                 []byte("token")}})
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	req := <-requests
+	assert.Equal(t, req.Headers.Get("Fastly-Key"), "token")
+	assert.Equal(t, req.Headers.Get("X-Header"), "value")
+	assert.Equal(t, req.Headers.Get("Authorization"), "")
+}
+
+func TestApplyGripConfigBasicAuth(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	config, err := ParseGripUri(strings.Replace(server.URL, "://",
+		"://user:pass@", 1) + "?header-X-Gateway=gw")
+	assert.Nil(t, err)
+	gpc := NewGripPubControl([]map[string]interface{}{config})
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	req := <-requests
+	assert.Equal(t, req.Headers.Get("Authorization"), "Basic dXNlcjpwYXNz")
+	assert.Equal(t, req.Headers.Get("X-Gateway"), "gw")
+}
+
+func TestApplyConfig(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.ApplyConfig([]map[string]interface{}{
+		map[string]interface{}{"uri": server.URL, "key": "token"}})
+	assert.Equal(t, len(gpc.clients), 1)
+	assert.Equal(t, gpc.clients[0].Uri(), server.URL)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, (<-requests).Headers.Get("Authorization"),
+		"Bearer token")
+}
+
+func TestAddClient(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddClient(pubcontrol.NewPubControlClient(server.URL))
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	assert.Equal(t, len(gpc.clients), 2)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	<-requests
+	<-requests
+	gpc.RemoveAllClients()
+	assert.Equal(t, len(gpc.clients), 0)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+}
+
 func TestPublishHttpResponse(t *testing.T) {
 	gpc := NewGripPubControl([]map[string]interface{}{
 		map[string]interface{}{
@@ -92,3 +149,119 @@ func TestGetHttpStreamItem(t *testing.T) {
 	assert.Nil(t, item)
 	assert.NotNil(t, err)
 }
+
+func TestPublishContext(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	ctx := context.Background()
+	assert.Nil(t, gpc.PublishHttpResponseContext(ctx, "chan", "data", "",
+		""))
+	assert.Equal(t, (<-requests).Items[0]["http-response"],
+		map[string]interface{}{"body": "data"})
+	assert.Nil(t, gpc.PublishHttpStreamContext(ctx, "chan", "data", "", ""))
+	assert.Equal(t, (<-requests).Items[0]["http-stream"],
+		map[string]interface{}{"content": "data"})
+	assert.NotNil(t, gpc.PublishHttpStreamContext(ctx, "chan", 1, "", ""))
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+	err := gpc.PublishHttpStreamContext(ctx, "chan", "data", "", "")
+	assert.NotNil(t, err)
+	assert.Contains(t, err.Error(), "context canceled")
+}
+
+func TestPublishFormats(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	err := gpc.PublishFormats("chan", []pubcontrol.Formatter{
+		&HttpResponseFormat{Body: []byte("body")},
+		&HttpStreamFormat{Content: []byte("content")},
+		&WebSocketMessageFormat{Content: []byte("message")}},
+		"id", "prev-id")
+	assert.Nil(t, err)
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id":       "prev-id",
+			"http-response": map[string]interface{}{"body": "body"},
+			"http-stream":   map[string]interface{}{"content": "content"},
+			"ws-message":    map[string]interface{}{"content": "message"}}})
+	err = gpc.PublishFormats("chan", nil, "", "")
+	assert.NotNil(t, err)
+}
+
+func TestGetFormatsItem(t *testing.T) {
+	formats := []pubcontrol.Formatter{&HttpResponseFormat{},
+		&HttpStreamFormat{}}
+	item, err := getFormatsItem(formats, "id", "prev-id")
+	assert.Nil(t, err)
+	assert.Equal(t, pubcontrol.NewItem(formats, "id", "prev-id"), item)
+	item, err = getFormatsItem(nil, "id", "prev-id")
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+	item, err = getFormatsItem([]pubcontrol.Formatter{&HttpStreamFormat{},
+		&HttpStreamFormat{}}, "id", "prev-id")
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+	item, err = getFormatsItem([]pubcontrol.Formatter{nil}, "id", "prev-id")
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+}
+
+func TestPublishWebSocketMessage(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	assert.Nil(t, gpc.PublishWebSocketMessage("chan", "data", "id",
+		"prev-id"))
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id":    "prev-id",
+			"ws-message": map[string]interface{}{"content": "data"}}})
+	assert.Nil(t, gpc.PublishWebSocketMessageContext(context.Background(),
+		"chan", []byte{0xff, 1, 2}, "", ""))
+	assert.Equal(t, (<-requests).Items[0]["ws-message"],
+		map[string]interface{}{"content-bin": "/wEC"})
+	assert.Nil(t, gpc.PublishWebSocketMessage("chan",
+		&WebSocketMessageFormat{Close: true, Code: 1000}, "", ""))
+	assert.Equal(t, (<-requests).Items[0]["ws-message"],
+		map[string]interface{}{"action": "close", "code": float64(1000)})
+	assert.NotNil(t, gpc.PublishWebSocketMessage("chan", 1, "", ""))
+}
+
+func TestGetWebSocketMessageItem(t *testing.T) {
+	item, err := getWebSocketMessageItem("data", "id", "prev-id")
+	assert.Nil(t, err)
+	assert.Equal(t, pubcontrol.NewItem([]pubcontrol.Formatter{
+		&WebSocketMessageFormat{Content: []byte("data")}}, "id", "prev-id"),
+		item)
+	item, err = getWebSocketMessageItem([]byte("data"), "id", "prev-id")
+	assert.Nil(t, err)
+	assert.Equal(t, pubcontrol.NewItem([]pubcontrol.Formatter{
+		&WebSocketMessageFormat{Content: []byte("data")}}, "id", "prev-id"),
+		item)
+	fmt := &WebSocketMessageFormat{Content: []byte("content")}
+	item, err = getWebSocketMessageItem(fmt, "id", "prev-id")
+	assert.Nil(t, err)
+	assert.Equal(t, pubcontrol.NewItem([]pubcontrol.Formatter{fmt},
+		"id", "prev-id"), item)
+	item, err = getWebSocketMessageItem(1, "id", "prev-id")
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+}
+
+func TestPublishHints(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	assert.Nil(t, gpc.PublishHttpResponseHint("chan", "id", "prev-id"))
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id":       "prev-id",
+			"http-response": map[string]interface{}{"action": "hint"}}})
+	assert.Nil(t, gpc.PublishHttpStreamHint("chan", "id", "prev-id"))
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id":     "prev-id",
+			"http-stream": map[string]interface{}{"action": "hint"}}})
+}
@@ -0,0 +1,344 @@
+//    grippubcontrolclient.go
+//    ~~~~~~~~~
+//    This module implements the GripPubControlClient struct and features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/golang-jwt/jwt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// The GripPubControlClient struct allows consumers to publish to a single
+// GRIP publishing endpoint. It provides the same authentication options as
+// PubControlClient and additionally allows arbitrary headers, such as the
+// Fastly-Key header required by Fastly Fanout, to be sent with each publish
+// request. A GripPubControlClient can also wrap an existing PubControlClient
+// in which case publishing is delegated to the wrapped instance.
+type GripPubControlClient struct {
+	uri           string
+	name          string
+	tags          []string
+	lock          *sync.Mutex
+	pcc           *pubcontrol.PubControlClient
+	authBasicUser string
+	authBasicPass string
+	authJwtClaim  map[string]interface{}
+	authJwtKey    []byte
+	authBearerKey string
+	headers       map[string]string
+	httpClient    *http.Client
+	breaker       *circuitBreaker
+}
+
+// Initialize this struct with a URL representing the publishing endpoint.
+func NewGripPubControlClient(uri string) *GripPubControlClient {
+	// These are the same transport settings that PubControlClient uses.
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   10 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSHandshakeTimeout:   7 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		MaxIdleConnsPerHost:   100,
+	}
+	gpcc := new(GripPubControlClient)
+	gpcc.uri = uri
+	gpcc.lock = &sync.Mutex{}
+	gpcc.headers = make(map[string]string)
+	gpcc.breaker = &circuitBreaker{probeInterval: DefaultProbeInterval}
+	gpcc.httpClient = &http.Client{Transport: transport,
+		Timeout: 15 * time.Second}
+	return gpcc
+}
+
+// An internal method for wrapping a PubControlClient instance so that it can
+// be managed alongside GripPubControlClient instances.
+func wrapPubControlClient(
+	pcc *pubcontrol.PubControlClient) *GripPubControlClient {
+	return &GripPubControlClient{lock: &sync.Mutex{}, pcc: pcc,
+		breaker: &circuitBreaker{probeInterval: DefaultProbeInterval}}
+}
+
+// Return the URI of the publishing endpoint. An empty string is returned
+// for wrapped PubControlClient instances.
+func (gpcc *GripPubControlClient) Uri() string {
+	return gpcc.uri
+}
+
+// Return the name of the endpoint, or an empty string if it is anonymous.
+func (gpcc *GripPubControlClient) Name() string {
+	gpcc.lock.Lock()
+	defer gpcc.lock.Unlock()
+	return gpcc.name
+}
+
+// Set the name used to identify the endpoint. Names should be set before
+// the client is added to a GripPubControl instance, which requires the
+// names of its clients to be unique.
+func (gpcc *GripPubControlClient) SetName(name string) {
+	gpcc.lock.Lock()
+	gpcc.name = name
+	gpcc.lock.Unlock()
+}
+
+// Return the tags of the endpoint.
+func (gpcc *GripPubControlClient) Tags() []string {
+	gpcc.lock.Lock()
+	defer gpcc.lock.Unlock()
+	return append([]string(nil), gpcc.tags...)
+}
+
+// Set the tags of the endpoint, such as a region or an environment, which
+// can be used to publish to a subset of the endpoints via WithPublishTags.
+func (gpcc *GripPubControlClient) SetTags(tags ...string) {
+	gpcc.lock.Lock()
+	gpcc.tags = append([]string(nil), tags...)
+	gpcc.lock.Unlock()
+}
+
+// Return whether the endpoint has any of the specified tags.
+func (gpcc *GripPubControlClient) HasTag(tags ...string) bool {
+	gpcc.lock.Lock()
+	defer gpcc.lock.Unlock()
+	for _, tag := range tags {
+		for _, own := range gpcc.tags {
+			if tag == own {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Call this method and pass a username and password to use basic
+// authentication with the configured endpoint.
+func (gpcc *GripPubControlClient) SetAuthBasic(username, password string) {
+	gpcc.lock.Lock()
+	gpcc.authBasicUser = username
+	gpcc.authBasicPass = password
+	gpcc.lock.Unlock()
+}
+
+// Call this method and pass a claim and key to use JWT authentication
+// with the configured endpoint.
+func (gpcc *GripPubControlClient) SetAuthJwt(claim map[string]interface{},
+	key []byte) {
+	gpcc.lock.Lock()
+	gpcc.authJwtClaim = claim
+	gpcc.authJwtKey = key
+	gpcc.lock.Unlock()
+}
+
+// Call this method and pass a key to use bearer authentication with the
+// configured endpoint.
+func (gpcc *GripPubControlClient) SetAuthBearer(key string) {
+	gpcc.lock.Lock()
+	gpcc.authBearerKey = key
+	gpcc.lock.Unlock()
+}
+
+// Call this method to send the specified header with every publish request
+// made to the configured endpoint. Headers set this way take precedence
+// over the Authorization header generated from the auth settings. An empty
+// value removes the header.
+func (gpcc *GripPubControlClient) SetHeader(name, value string) {
+	gpcc.lock.Lock()
+	if value == "" {
+		delete(gpcc.headers, name)
+	} else {
+		gpcc.headers[name] = value
+	}
+	gpcc.lock.Unlock()
+}
+
+// The publish method for publishing the specified item to the specified
+// channel on the configured endpoint.
+func (gpcc *GripPubControlClient) Publish(channel string,
+	item *pubcontrol.Item) error {
+	return gpcc.PublishContext(context.Background(), channel, item)
+}
+
+// The publish method for publishing the specified item to the specified
+// channel on the configured endpoint. The publish request is canceled when
+// the specified context is canceled or its deadline is exceeded. Wrapped
+// PubControlClient instances cannot be canceled mid-request, but this method
+// still returns as soon as the context is done.
+func (gpcc *GripPubControlClient) PublishContext(ctx context.Context,
+	channel string, item *pubcontrol.Item) error {
+	items := []*ChannelItem{&ChannelItem{Channel: channel, Item: item}}
+	exports, err := exportChannelItems(items)
+	if err != nil {
+		return err
+	}
+	result := gpcc.publishExports(ctx, exports)
+	if result.Err != nil {
+		return result.Err
+	}
+	return nil
+}
+
+// An internal method for publishing the specified exported items in a
+// single request and returning the outcome. Wrapped PubControlClient
+// instances publish the items one at a time instead.
+func (gpcc *GripPubControlClient) publishExports(ctx context.Context,
+	exports []map[string]interface{}) *EndpointResult {
+	result := &EndpointResult{Uri: gpcc.uri, Name: gpcc.Name()}
+	if err := ctx.Err(); err != nil {
+		result.Err = &EndpointError{Uri: gpcc.uri, Err: err, callerDone: true}
+		return result
+	}
+	if !gpcc.breaker.allow() {
+		result.Err = &EndpointError{Uri: gpcc.uri, Err: ErrCircuitOpen}
+		return result
+	}
+	// A panic counts as a failure so that a half-open circuit breaker does
+	// not wait forever for the outcome of its probe.
+	defer func() {
+		if err := recover(); err != nil {
+			gpcc.breaker.record(&EndpointError{Uri: gpcc.uri,
+				Err: &GripPublishError{err: fmt.Sprintf("PANIC: %v", err)}})
+			panic(err)
+		}
+	}()
+	start := time.Now()
+	var err error
+	if gpcc.pcc != nil {
+		err = gpcc.publishWrapped(ctx, exports)
+	} else {
+		var content []byte
+		content, err = json.Marshal(map[string]interface{}{"items": exports})
+		if err == nil {
+			result.Bytes = len(content)
+			result.StatusCode, result.Body, err = gpcc.pubCall(ctx, content)
+		}
+	}
+	result.Latency = time.Since(start)
+	failed := gpcc.pcc == nil &&
+		(result.StatusCode < 200 || result.StatusCode >= 300)
+	if err != nil || failed {
+		// A failure is only attributed to the caller if its context is
+		// done, so that timeouts of the HTTP client count as timeouts of
+		// the endpoint.
+		result.Err = &EndpointError{Uri: gpcc.uri,
+			StatusCode: result.StatusCode, Body: result.Body, Err: err,
+			callerDone: err != nil && ctx.Err() != nil}
+	}
+	gpcc.breaker.record(result.Err)
+	return result
+}
+
+// An internal method for publishing the specified exported items via the
+// wrapped PubControlClient instance. The items are rebuilt from the exports
+// so that IDs assigned by the sequencer are included.
+func (gpcc *GripPubControlClient) publishWrapped(ctx context.Context,
+	exports []map[string]interface{}) error {
+	errCh := make(chan error, 1)
+	go func() {
+		for _, export := range exports {
+			item := exportedChannelItem(export)
+			if err := gpcc.pcc.Publish(item.Channel, item.Item); err != nil {
+				errCh <- err
+				return
+			}
+		}
+		errCh <- nil
+	}()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// An internal method for making the HTTP POST request for publishing the
+// specified JSON content to the endpoint. The HTTP status code and
+// response body are returned.
+func (gpcc *GripPubControlClient) pubCall(ctx context.Context,
+	content []byte) (int, []byte, error) {
+	gpcc.lock.Lock()
+	uri := gpcc.uri + "/publish/"
+	headers, err := gpcc.generateHeaders()
+	gpcc.lock.Unlock()
+	if err != nil {
+		return 0, nil, err
+	}
+	return gpcc.makeHttpRequest(ctx, uri, headers, content)
+}
+
+// An internal method used to generate the request headers. The
+// Authorization header is generated based on whether basic, JWT or bearer
+// authorization information was provided, and any headers set via the
+// SetHeader method are then added. The lock must be held by the caller.
+func (gpcc *GripPubControlClient) generateHeaders() (map[string]string,
+	error) {
+	headers := make(map[string]string)
+	if gpcc.authBasicUser != "" {
+		headers["Authorization"] = "Basic " +
+			base64.StdEncoding.EncodeToString(
+				[]byte(gpcc.authBasicUser+":"+gpcc.authBasicPass))
+	} else if gpcc.authJwtClaim != nil {
+		token := jwt.New(jwt.SigningMethodHS256)
+		claims := token.Claims.(jwt.MapClaims)
+		for k, v := range gpcc.authJwtClaim {
+			claims[k] = v
+		}
+		if _, ok := gpcc.authJwtClaim["exp"]; !ok {
+			claims["exp"] = time.Now().Add(time.Second * 3600).Unix()
+		}
+		tokenString, err := token.SignedString(gpcc.authJwtKey)
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = "Bearer " + tokenString
+	} else if gpcc.authBearerKey != "" {
+		headers["Authorization"] = "Bearer " + gpcc.authBearerKey
+	}
+	for name, value := range gpcc.headers {
+		headers[name] = value
+	}
+	return headers, nil
+}
+
+// An internal method used to make the HTTP request for publishing based
+// on the specified context, URI, headers, and JSON content. An HTTP status
+// code, response body, and an error will be returned.
+func (gpcc *GripPubControlClient) makeHttpRequest(ctx context.Context,
+	uri string, headers map[string]string, content []byte) (int, []byte,
+	error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", uri,
+		bytes.NewReader(content))
+	if err != nil {
+		return 0, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := gpcc.httpClient.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
@@ -0,0 +1,190 @@
+//    grippubcontrolclient_test.go
+//    ~~~~~~~~~
+//    This module implements the GripPubControlClient tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/golang-jwt/jwt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// An internal type used to record the requests received by a test server.
+type testPublishRequest struct {
+	Path    string
+	Headers http.Header
+	Items   []map[string]interface{}
+}
+
+// An internal method for starting a test server that records publish
+// requests and responds with the specified status code.
+func newTestPublishServer(t *testing.T, statusCode int) (*httptest.Server,
+	chan *testPublishRequest) {
+	requests := make(chan *testPublishRequest, 100)
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			content := make(map[string][]map[string]interface{})
+			json.Unmarshal(body, &content)
+			requests <- &testPublishRequest{Path: r.URL.Path,
+				Headers: r.Header, Items: content["items"]}
+			w.WriteHeader(statusCode)
+			io.WriteString(w, "result")
+		}))
+	t.Cleanup(server.Close)
+	return server, requests
+}
+
+// An internal method for starting a test server that never responds to
+// publish requests and a client for it that times out after the specified
+// duration.
+func newTestHangingClient(t *testing.T,
+	timeout time.Duration) *GripPubControlClient {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			io.ReadAll(r.Body)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) })
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.httpClient.Timeout = timeout
+	return gpcc
+}
+
+func TestGripPubControlClientPublish(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpcc := NewGripPubControlClient(server.URL)
+	assert.Equal(t, gpcc.Uri(), server.URL)
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "id", "prev-id")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	req := <-requests
+	assert.Equal(t, req.Path, "/publish/")
+	assert.Equal(t, req.Headers.Get("Content-Type"), "application/json")
+	assert.Equal(t, req.Headers.Get("Authorization"), "")
+	assert.Equal(t, req.Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id": "prev-id", "http-stream": map[string]interface{}{
+				"content": "data"}}})
+}
+
+func TestGripPubControlClientAuth(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "", "")
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.SetAuthBearer("token")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	assert.Equal(t, (<-requests).Headers.Get("Authorization"),
+		"Bearer token")
+	gpcc = NewGripPubControlClient(server.URL)
+	gpcc.SetAuthBasic("user", "pass")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	assert.Equal(t, (<-requests).Headers.Get("Authorization"),
+		"Basic dXNlcjpwYXNz")
+	gpcc = NewGripPubControlClient(server.URL)
+	gpcc.SetAuthJwt(map[string]interface{}{"iss": "realm"}, []byte("key"))
+	assert.Nil(t, gpcc.Publish("chan", item))
+	auth := (<-requests).Headers.Get("Authorization")
+	assert.True(t, strings.HasPrefix(auth, "Bearer "))
+	token, err := jwt.Parse(auth[7:], func(t *jwt.Token) (interface{},
+		error) {
+		return []byte("key"), nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, token.Claims.(jwt.MapClaims)["iss"], "realm")
+}
+
+func TestGripPubControlClientSetHeader(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "", "")
+	gpcc := NewGripPubControlClient(server.URL)
+	gpcc.SetAuthBearer("token")
+	gpcc.SetHeader("Fastly-Key", "api-token")
+	gpcc.SetHeader("X-Other", "other")
+	gpcc.SetHeader("X-Other", "")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	req := <-requests
+	assert.Equal(t, req.Headers.Get("Fastly-Key"), "api-token")
+	assert.Equal(t, req.Headers.Get("Authorization"), "Bearer token")
+	assert.Equal(t, req.Headers.Get("X-Other"), "")
+	gpcc.SetHeader("Authorization", "Custom")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	assert.Equal(t, (<-requests).Headers.Get("Authorization"), "Custom")
+}
+
+func TestGripPubControlClientPublishFailure(t *testing.T) {
+	server, _ := newTestPublishServer(t, 500)
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "", "")
+	gpcc := NewGripPubControlClient(server.URL)
+	err := gpcc.Publish("chan", item)
+	assert.NotNil(t, err)
+	assert.Equal(t, err.Error(),
+		"Failure status code: 500 with message: result")
+	gpcc = NewGripPubControlClient("something://uri")
+	assert.NotNil(t, gpcc.Publish("chan", item))
+	item = pubcontrol.NewItem([]pubcontrol.Formatter{&HttpStreamFormat{},
+		&HttpStreamFormat{}}, "", "")
+	assert.NotNil(t, NewGripPubControlClient(server.URL).Publish("chan", item))
+}
+
+func TestGripPubControlClientWrapped(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpcc := wrapPubControlClient(pubcontrol.NewPubControlClient(server.URL))
+	assert.Equal(t, gpcc.Uri(), "")
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "", "")
+	assert.Nil(t, gpcc.Publish("chan", item))
+	assert.Equal(t, (<-requests).Items[0]["channel"], "chan")
+}
+
+func TestGripPubControlClientPublishContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+	defer server.Close()
+	defer close(release)
+	item := pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpStreamFormat{Content: []byte("data")}}, "", "")
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := NewGripPubControlClient(server.URL).PublishContext(ctx, "chan",
+		item)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, time.Since(start) < 5*time.Second)
+	err = wrapPubControlClient(pubcontrol.NewPubControlClient(
+		server.URL)).PublishContext(ctx, "chan", item)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	err = NewGripPubControlClient(server.URL).PublishContext(ctx, "chan",
+		item)
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,327 @@
+//    history.go
+//    ~~~~~~~~~
+//    This module implements the History struct and the HistoryStore
+//    interface.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// The default maximum number of items that the in-memory history store
+// keeps per channel.
+const DefaultHistorySize = 100
+
+// The error matched via errors.Is when the items published after an ID
+// cannot be recovered because the ID is no longer in the history.
+var ErrHistoryGap = errors.New("id is not in the channel history")
+
+// The HistoryEntry struct holds a single published item as it was sent to
+// the GRIP proxies, including its 'id' and 'prev-id'.
+type HistoryEntry struct {
+	Id        string
+	Export    map[string]interface{}
+	Published time.Time
+}
+
+// The HistoryStore interface holds the items published to each channel.
+// Implementations are responsible for bounding the number of items they
+// keep.
+type HistoryStore interface {
+
+	// Add the specified entry to the history of the specified channel.
+	Append(ctx context.Context, channel string, entry *HistoryEntry) error
+
+	// Return the entries of the specified channel, oldest first.
+	Entries(ctx context.Context, channel string) ([]*HistoryEntry, error)
+}
+
+// An internal struct implementing an in-memory HistoryStore.
+type memoryHistoryStore struct {
+	lock     sync.Mutex
+	maxItems int
+	maxAge   time.Duration
+	channels map[string][]*HistoryEntry
+}
+
+// Create a HistoryStore that keeps up to the specified number of items per
+// channel in memory, discarding items older than the specified age. A size
+// of zero or less uses DefaultHistorySize and an age of zero or less keeps
+// items regardless of their age.
+func NewMemoryHistoryStore(maxItems int,
+	maxAge time.Duration) HistoryStore {
+	if maxItems <= 0 {
+		maxItems = DefaultHistorySize
+	}
+	return &memoryHistoryStore{maxItems: maxItems, maxAge: maxAge,
+		channels: make(map[string][]*HistoryEntry)}
+}
+
+// Add the specified entry and discard the entries that exceed the bounds.
+func (store *memoryHistoryStore) Append(ctx context.Context, channel string,
+	entry *HistoryEntry) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	entries := store.trim(append(store.channels[channel], entry))
+	if len(entries) > store.maxItems {
+		entries = entries[len(entries)-store.maxItems:]
+	}
+	// The entries are copied so that the discarded ones can be collected.
+	store.channels[channel] = append([]*HistoryEntry(nil), entries...)
+	return nil
+}
+
+// Return the entries of the specified channel that are within the bounds.
+func (store *memoryHistoryStore) Entries(ctx context.Context,
+	channel string) ([]*HistoryEntry, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	entries := store.trim(store.channels[channel])
+	if len(entries) == 0 {
+		delete(store.channels, channel)
+		return nil, nil
+	}
+	store.channels[channel] = entries
+	return append([]*HistoryEntry(nil), entries...), nil
+}
+
+// An internal method for discarding the entries older than the maximum
+// age. The lock must be held by the caller.
+func (store *memoryHistoryStore) trim(
+	entries []*HistoryEntry) []*HistoryEntry {
+	if store.maxAge <= 0 {
+		return entries
+	}
+	cutoff := time.Now().Add(-store.maxAge)
+	for len(entries) > 0 && entries[0].Published.Before(cutoff) {
+		entries = entries[1:]
+	}
+	return entries
+}
+
+// The History struct records the items published to each channel so that
+// the items missed by a reconnecting client can be recovered. Only items
+// with an ID are recorded, which makes it a natural fit for use alongside
+// a Sequencer.
+type History struct {
+	store HistoryStore
+}
+
+// Create a History that uses the specified store, or an in-memory store
+// with the default size if store is nil.
+func NewHistory(store HistoryStore) *History {
+	if store == nil {
+		store = NewMemoryHistoryStore(0, 0)
+	}
+	return &History{store: store}
+}
+
+// Set the history that the items published via this GripPubControl
+// instance are recorded in. Items are recorded once at least one endpoint
+// has accepted them, so that items that no endpoint received cannot be
+// recovered. Setting a nil history disables recording.
+func (gpc *GripPubControl) SetHistory(history *History) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	gpc.history = history
+}
+
+// Record the specified exported item in the history of its channel. Items
+// without an ID are ignored. The entry holds a copy of the item so that
+// later changes to it are not recorded.
+func (history *History) Record(ctx context.Context,
+	export map[string]interface{}) error {
+	id, _ := export["id"].(string)
+	channel, _ := export["channel"].(string)
+	if id == "" {
+		return nil
+	}
+	return history.store.Append(ctx, channel, &HistoryEntry{Id: id,
+		Export:    copyExportValue(export).(map[string]interface{}),
+		Published: time.Now()})
+}
+
+// An internal function for returning a deep copy of the specified value of
+// an exported item. Maps and slices are copied, while other values are
+// returned as is.
+func copyExportValue(value interface{}) interface{} {
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			copied[key] = copyExportValue(item)
+		}
+		return copied
+	case map[string]string:
+		copied := make(map[string]string, len(typed))
+		for key, item := range typed {
+			copied[key] = item
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(typed))
+		for i, item := range typed {
+			copied[i] = copyExportValue(item)
+		}
+		return copied
+	case []byte:
+		return append([]byte(nil), typed...)
+	}
+	return value
+}
+
+// Return the entries published to the specified channel after the entry
+// with the specified ID, oldest first. All of the entries are returned if
+// lastId is empty, and an error matching ErrHistoryGap is returned if the
+// ID is no longer in the history.
+func (history *History) Since(ctx context.Context, channel,
+	lastId string) ([]*HistoryEntry, error) {
+	entries, err := history.store.Entries(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+	if lastId == "" {
+		return entries, nil
+	}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Id == lastId {
+			return entries[i+1:], nil
+		}
+	}
+	return nil, ErrHistoryGap
+}
+
+// The Recovery struct holds the entries missed by a reconnecting HTTP
+// streaming client, the response body made up of their stream content and
+// the channel to hold the client on with its previous ID set to the ID of
+// the last entry.
+type Recovery struct {
+	Entries []*HistoryEntry
+	Body    []byte
+	Channel *Channel
+}
+
+// Recover the items published to the specified channel after the specified
+// ID. If lastId is empty then no items are returned, and the channel's
+// previous ID is set to the ID of the most recent entry so that the client
+// only receives the items published from now on.
+func (history *History) Recover(ctx context.Context, channel,
+	lastId string) (*Recovery, error) {
+	recovery := &Recovery{Entries: make([]*HistoryEntry, 0),
+		Body: make([]byte, 0), Channel: &Channel{Name: channel,
+			PrevId: lastId}}
+	entries, err := history.Since(ctx, channel, lastId)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return recovery, nil
+	}
+	recovery.Channel.PrevId = entries[len(entries)-1].Id
+	if lastId == "" {
+		return recovery, nil
+	}
+	recovery.Entries = entries
+	for _, entry := range entries {
+		recovery.Body = append(recovery.Body,
+			getHistoryStreamContent(entry.Export)...)
+	}
+	return recovery, nil
+}
+
+// Recover the items of the specified channel that were missed by the
+// client that made the specified request. The last ID seen by the client
+// is taken from the Grip-Last header set by the GRIP proxy, or from the
+// Last-Event-ID header if there is no Grip-Last entry for the channel.
+func (history *History) RecoverRequest(ctx context.Context, r *http.Request,
+	channel string) (*Recovery, error) {
+	return history.Recover(ctx, channel, GetLastId(r, channel))
+}
+
+// Create GRIP hold stream instructions for the client that made the
+// specified request on the specified channel. The instructions respond
+// with the items the client missed and hold the client with the correct
+// previous ID.
+func (history *History) CreateRecoveryHoldStream(ctx context.Context,
+	r *http.Request, channel string) (string, error) {
+	recovery, err := history.RecoverRequest(ctx, r, channel)
+	if err != nil {
+		return "", err
+	}
+	return CreateHoldStream([]*Channel{recovery.Channel},
+		&Response{Body: recovery.Body})
+}
+
+// Return the last ID seen for the specified channel by the client that made
+// the specified request, based on the Grip-Last header or otherwise the
+// Last-Event-ID header. An empty string is returned if neither is set.
+func GetLastId(r *http.Request, channel string) string {
+	for _, header := range r.Header.Values("Grip-Last") {
+		for _, part := range strings.Split(header, ",") {
+			params := strings.Split(part, ";")
+			if strings.TrimSpace(params[0]) != channel {
+				continue
+			}
+			for _, param := range params[1:] {
+				name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+				if strings.TrimSpace(name) == "last-id" {
+					return strings.TrimSpace(value)
+				}
+			}
+		}
+	}
+	return r.Header.Get("Last-Event-ID")
+}
+
+// An internal method for returning the HTTP stream content of the specified
+// exported item. Items without HTTP stream content return nil.
+func getHistoryStreamContent(export map[string]interface{}) []byte {
+	stream, ok := export["http-stream"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if content, ok := stream["content"].(string); ok {
+		return []byte(content)
+	}
+	if encoded, ok := stream["content-bin"].(string); ok {
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil {
+			return content
+		}
+	}
+	return nil
+}
+
+// An internal method for recording the specified exports of the specified
+// accepted channels in the history, if one is set. Since the items have
+// already been published, failures are logged rather than returned.
+func (gpc *GripPubControl) recordExports(ctx context.Context,
+	exports []map[string]interface{}, accepted map[string]bool) {
+	gpc.settingsRWLock.RLock()
+	history := gpc.history
+	gpc.settingsRWLock.RUnlock()
+	if history == nil {
+		return
+	}
+	for _, export := range exports {
+		channel, _ := export["channel"].(string)
+		if !accepted[channel] {
+			continue
+		}
+		if err := history.Record(ctx, export); err != nil {
+			gpc.getLogger().WarnContext(ctx, "failed to record history",
+				slog.String(LogKeyChannel, channel), slog.Any("error", err))
+		}
+	}
+}
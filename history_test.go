@@ -0,0 +1,168 @@
+//    history_test.go
+//    ~~~~~~~~~
+//    This module implements the History tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// An internal method for recording a stream item with the specified ID and
+// content in the specified history.
+func recordTestHistory(history *History, channel, id, content string) {
+	history.Record(context.Background(), map[string]interface{}{
+		"channel": channel, "id": id, "http-stream": map[string]interface{}{
+			"content": content}})
+}
+
+func TestMemoryHistoryStoreBounds(t *testing.T) {
+	history := NewHistory(NewMemoryHistoryStore(3, 0))
+	for i := 1; i <= 5; i++ {
+		recordTestHistory(history, "chan", strconv.Itoa(i), "")
+	}
+	history.Record(context.Background(), map[string]interface{}{
+		"channel": "chan"})
+	entries, err := history.Since(context.Background(), "chan", "")
+	assert.Nil(t, err)
+	assert.Equal(t, len(entries), 3)
+	assert.Equal(t, entries[0].Id, "3")
+	assert.Equal(t, entries[2].Id, "5")
+	_, err = history.Since(context.Background(), "chan", "1")
+	assert.True(t, errors.Is(err, ErrHistoryGap))
+	entries, _ = history.Since(context.Background(), "chan", "4")
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Id, "5")
+	entries, _ = history.Since(context.Background(), "other", "")
+	assert.Equal(t, len(entries), 0)
+
+	history = NewHistory(NewMemoryHistoryStore(0, 50*time.Millisecond))
+	recordTestHistory(history, "chan", "1", "")
+	time.Sleep(100 * time.Millisecond)
+	recordTestHistory(history, "chan", "2", "")
+	entries, _ = history.Since(context.Background(), "chan", "")
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Id, "2")
+}
+
+func TestHistoryRecover(t *testing.T) {
+	history := NewHistory(nil)
+	recovery, err := history.Recover(context.Background(), "chan", "")
+	assert.Nil(t, err)
+	assert.Equal(t, recovery.Channel, &Channel{Name: "chan"})
+	recordTestHistory(history, "chan", "1", "a\n")
+	recordTestHistory(history, "chan", "2", "b\n")
+	history.Record(context.Background(), map[string]interface{}{
+		"channel": "chan", "id": "3", "http-stream": map[string]interface{}{
+			"content-bin": "Yw=="}})
+	recovery, _ = history.Recover(context.Background(), "chan", "")
+	assert.Equal(t, len(recovery.Entries), 0)
+	assert.Equal(t, recovery.Body, []byte{})
+	assert.Equal(t, recovery.Channel.PrevId, "3")
+	recovery, _ = history.Recover(context.Background(), "chan", "1")
+	assert.Equal(t, len(recovery.Entries), 2)
+	assert.Equal(t, string(recovery.Body), "b\nc")
+	assert.Equal(t, recovery.Channel.PrevId, "3")
+	recovery, _ = history.Recover(context.Background(), "chan", "3")
+	assert.Equal(t, len(recovery.Entries), 0)
+	assert.Equal(t, recovery.Channel.PrevId, "3")
+	_, err = history.Recover(context.Background(), "chan", "0")
+	assert.True(t, errors.Is(err, ErrHistoryGap))
+}
+
+func TestGetLastId(t *testing.T) {
+	r, _ := http.NewRequest("GET", "http://localhost/", nil)
+	assert.Equal(t, GetLastId(r, "chan"), "")
+	r.Header.Set("Last-Event-ID", "5")
+	assert.Equal(t, GetLastId(r, "chan"), "5")
+	r.Header.Add("Grip-Last", "other; last-id=1")
+	r.Header.Add("Grip-Last", "a; last-id=2, chan; last-id=3")
+	assert.Equal(t, GetLastId(r, "chan"), "3")
+	assert.Equal(t, GetLastId(r, "other"), "1")
+	assert.Equal(t, GetLastId(r, "b"), "5")
+}
+
+func TestCreateRecoveryHoldStream(t *testing.T) {
+	history := NewHistory(nil)
+	recordTestHistory(history, "chan", "1", "a\n")
+	recordTestHistory(history, "chan", "2", "b\n")
+	r, _ := http.NewRequest("GET", "http://localhost/", nil)
+	r.Header.Set("Grip-Last", "chan; last-id=1")
+	instruct, err := history.CreateRecoveryHoldStream(context.Background(),
+		r, "chan")
+	assert.Nil(t, err)
+	var out map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(instruct), &out))
+	assert.Equal(t, out, map[string]interface{}{
+		"hold": map[string]interface{}{"mode": "stream",
+			"channels": []interface{}{map[string]interface{}{
+				"name": "chan", "prev-id": "2"}}},
+		"response": map[string]interface{}{"body": "b\n"}})
+	r.Header.Set("Grip-Last", "chan; last-id=0")
+	_, err = history.CreateRecoveryHoldStream(context.Background(), r, "chan")
+	assert.True(t, errors.Is(err, ErrHistoryGap))
+}
+
+func TestSetHistory(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	history := NewHistory(nil)
+	gpc.SetHistory(history)
+	gpc.SetSequencer(NewSequencer(nil))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "a", "", ""))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "b", "", ""))
+	<-requests
+	<-requests
+	recovery, err := history.Recover(context.Background(), "chan", "1")
+	assert.Nil(t, err)
+	assert.Equal(t, string(recovery.Body), "b")
+	assert.Equal(t, recovery.Channel.PrevId, "2")
+	gpc.SetSequencer(nil)
+	assert.Nil(t, gpc.Publish("chan", pubcontrol.NewItem(
+		[]pubcontrol.Formatter{&HttpStreamFormat{Content: []byte("c")}},
+		"custom", "2")))
+	entries, _ := history.Since(context.Background(), "chan", "2")
+	assert.Equal(t, len(entries), 1)
+	assert.Equal(t, entries[0].Export["prev-id"], "2")
+	assert.Nil(t, gpc.PublishHttpStream("chan", "d", "", ""))
+	entries, _ = history.Since(context.Background(), "chan", "")
+	assert.Equal(t, len(entries), 3)
+}
+
+func TestSetHistoryFailedPublish(t *testing.T) {
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient("http://127.0.0.1:1"))
+	history := NewHistory(nil)
+	gpc.SetHistory(history)
+	assert.NotNil(t, gpc.Publish("chan", pubcontrol.NewItem(
+		[]pubcontrol.Formatter{&HttpStreamFormat{Content: []byte("a")}},
+		"1", "")))
+	entries, err := history.Since(context.Background(), "chan", "")
+	assert.Nil(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestHistoryRecordCopiesExport(t *testing.T) {
+	history := NewHistory(nil)
+	export := map[string]interface{}{"channel": "chan", "id": "1",
+		"http-stream": map[string]interface{}{"content": "a"}}
+	assert.Nil(t, history.Record(context.Background(), export))
+	export["prev-id"] = "0"
+	export["http-stream"].(map[string]interface{})["content"] = "b"
+	entries, _ := history.Since(context.Background(), "chan", "")
+	assert.Equal(t, entries[0].Export, map[string]interface{}{
+		"channel": "chan", "id": "1",
+		"http-stream": map[string]interface{}{"content": "a"}})
+}
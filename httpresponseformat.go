@@ -11,12 +11,18 @@ import "encoding/base64"
 import "unicode/utf8"
 
 // The HttpResponseFormat struct is the format used to publish messages to
-// HTTP response clients connected to a GRIP proxy.
+// HTTP response clients connected to a GRIP proxy. Setting Hint causes the
+// GRIP proxy to retry the held requests against the origin rather than
+// responding with the content of the message. BodyPatch can be set to a
+// list of JSON Patch operations that the GRIP proxy applies to the body of
+// the held response instead of replacing it.
 type HttpResponseFormat struct {
-	Code    int
-	Reason  string
-	Headers map[string]string
-	Body    []byte
+	Code      int
+	Reason    string
+	Headers   map[string]string
+	Body      []byte
+	BodyPatch []*JsonPatchOperation
+	Hint      bool
 }
 
 // The name used when publishing this format.
@@ -26,9 +32,14 @@ func (format *HttpResponseFormat) Name() string {
 
 // Export the message into the required format and include only the fields
 // that are set. The body is exported as base64 as 'body-bin' (as opposed
-// to 'body') if the value is a buffer.
+// to 'body') if the value is a buffer, and the body patch is exported as
+// 'body-patch'. Only the action is exported when the message is a hint.
 func (format *HttpResponseFormat) Export() interface{} {
 	export := make(map[string]interface{})
+	if format.Hint {
+		export["action"] = "hint"
+		return export
+	}
 	if format.Code > 0 {
 		export["code"] = format.Code
 	}
@@ -47,5 +58,12 @@ func (format *HttpResponseFormat) Export() interface{} {
 				base64.StdEncoding.EncodeToString(format.Body)
 		}
 	}
+	if len(format.BodyPatch) > 0 {
+		patch := make([]map[string]interface{}, 0, len(format.BodyPatch))
+		for _, op := range format.BodyPatch {
+			patch = append(patch, op.Export())
+		}
+		export["body-patch"] = patch
+	}
 	return export
 }
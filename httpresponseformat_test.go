@@ -32,4 +32,14 @@ func TestHttpResponseFormatExport(t *testing.T) {
 		Body: []byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF")}
 	assert.Equal(t, fmt.Export(), map[string]interface{}{"body-bin": base64.StdEncoding.EncodeToString(
 		[]byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF"))})
+	fmt = &HttpResponseFormat{Code: 1, Body: []byte("body"), Hint: true}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "hint"})
+	fmt = &HttpResponseFormat{BodyPatch: []*JsonPatchOperation{
+		NewJsonPatchReplace("/count", 2), NewJsonPatchRemove("/old")}}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"body-patch": []map[string]interface{}{
+			map[string]interface{}{"op": "replace", "path": "/count",
+				"value": 2},
+			map[string]interface{}{"op": "remove", "path": "/old"}}})
 }
@@ -11,10 +11,15 @@ import "encoding/base64"
 import "unicode/utf8"
 
 // The HttpStreamFormat struct is the format used to publish messages to
-// HTTP stream clients connected to a GRIP proxy.
+// HTTP stream clients connected to a GRIP proxy. Setting Hint causes the
+// GRIP proxy to fetch the content from the origin rather than it being
+// included in the message, and setting Refresh causes the GRIP proxy to
+// make a new request to the origin for each stream.
 type HttpStreamFormat struct {
 	Content []byte
 	Close   bool
+	Hint    bool
+	Refresh bool
 }
 
 // The name used when publishing this format.
@@ -23,12 +28,16 @@ func (format *HttpStreamFormat) Name() string {
 }
 
 // Exports the message in the required format depending on whether the
-// message content is binary or not, or whether the connection should
-// be closed.
+// message content is binary or not, or whether the message is a close,
+// refresh or hint action.
 func (format *HttpStreamFormat) Export() interface{} {
 	export := make(map[string]interface{})
 	if format.Close {
 		export["action"] = "close"
+	} else if format.Refresh {
+		export["action"] = "refresh"
+	} else if format.Hint {
+		export["action"] = "hint"
 	} else {
 		if format.Content != nil {
 			content := string(format.Content)
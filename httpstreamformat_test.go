@@ -34,4 +34,13 @@ func TestHttpStreamFormatExport(t *testing.T) {
 		Content: []byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF")}
 	assert.Equal(t, fmt.Export(), map[string]interface{}{"content-bin": base64.StdEncoding.EncodeToString(
 		[]byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF"))})
+	fmt = &HttpStreamFormat{Content: []byte("content"), Hint: true}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "hint"})
+	fmt = &HttpStreamFormat{Hint: true, Refresh: true}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "refresh"})
+	fmt = &HttpStreamFormat{Close: true, Refresh: true}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "close"})
 }
@@ -0,0 +1,259 @@
+//    interceptor.go
+//    ~~~~~~~~~
+//    This module implements the publish interceptor chain.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"fmt"
+	"github.com/fanout/go-pubcontrol"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// The PublishHandler type represents the next step of the interceptor
+// chain, which publishes the specified item to the specified channel and
+// returns the outcome.
+type PublishHandler func(ctx context.Context, channel string,
+	item *pubcontrol.Item) (*PublishResult, error)
+
+// The Interceptor type represents a step of the interceptor chain. An
+// interceptor can inspect the channel and item, pass a different channel,
+// item or context on to next, reject the publish by returning an error
+// without calling next, and observe the result returned by next. The next
+// handler must be called at most once and before the interceptor returns.
+// An interceptor may be called concurrently for different items.
+type Interceptor func(ctx context.Context, channel string,
+	item *pubcontrol.Item, next PublishHandler) (*PublishResult, error)
+
+// Add the specified interceptor to the end of the interceptor chain, so
+// that interceptors run in the order in which they are added. Every item
+// published via this GripPubControl instance passes through the chain.
+// The items of a batch or of a multi-channel publish pass through the chain
+// concurrently, each in its own goroutine, and are then sent together, in
+// which case each interceptor observes the result of the whole batch.
+// Interceptors must therefore be safe for concurrent use. For PublishAsync
+// the chain runs in the background once the item is taken from the queue,
+// and observes the result of publishing it including any retries. An error
+// returned by the chain is then passed to the callback of the item.
+func (gpc *GripPubControl) AddInterceptor(interceptor Interceptor) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	// The chain is copied since publishes in progress may be using it.
+	interceptors := make([]Interceptor, 0, len(gpc.interceptors)+1)
+	interceptors = append(interceptors, gpc.interceptors...)
+	gpc.interceptors = append(interceptors, interceptor)
+}
+
+// An internal method for returning the interceptor chain.
+func (gpc *GripPubControl) getInterceptors() []Interceptor {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	return gpc.interceptors
+}
+
+// An internal type used as the key of the item meta context value.
+type itemMetaKey struct{}
+
+// Return a copy of the specified context that adds the specified key and
+// value to the 'meta' of the items published with it. This is typically
+// used by interceptors to stamp items with values such as tracing IDs.
+func WithItemMeta(ctx context.Context, key, value string) context.Context {
+	meta := make(map[string]string)
+	for k, v := range itemMetaFromContext(ctx) {
+		meta[k] = v
+	}
+	meta[key] = value
+	return context.WithValue(ctx, itemMetaKey{}, meta)
+}
+
+// An internal method for returning the item meta of the specified context.
+func itemMetaFromContext(ctx context.Context) map[string]string {
+	meta, _ := ctx.Value(itemMetaKey{}).(map[string]string)
+	return meta
+}
+
+// An internal method for merging the item meta of the specified context
+// into the 'meta' of the specified export.
+func applyItemMeta(ctx context.Context, export map[string]interface{}) {
+	meta := itemMetaFromContext(ctx)
+	if len(meta) == 0 {
+		return
+	}
+	merged := make(map[string]interface{})
+	switch existing := export["meta"].(type) {
+	case map[string]string:
+		for k, v := range existing {
+			merged[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range meta {
+		merged[k] = v
+	}
+	export["meta"] = merged
+}
+
+// An internal method for wrapping the specified handler in the specified
+// interceptors, the first of which runs first.
+func chainInterceptors(interceptors []Interceptor,
+	handler PublishHandler) PublishHandler {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], handler
+		handler = func(ctx context.Context, channel string,
+			item *pubcontrol.Item) (*PublishResult, error) {
+			return interceptor(ctx, channel, item, next)
+		}
+	}
+	return handler
+}
+
+// The error returned by the next handler of an interceptor chain when it is
+// called more than once.
+var errNextCalledTwice = &GripPublishError{err: "next must only be called " +
+	"once"}
+
+// An internal function type that sends the items that reached the end of
+// the interceptor chain. The item meta of each item is taken from the
+// context at the same index of ctxs, or from ctx if ctxs is nil.
+type interceptedSender func(ctx context.Context, items []*ChannelItem,
+	ctxs []context.Context) (*PublishResult, error)
+
+// An internal method for passing each of the specified items through the
+// interceptor chain and sending the items that reach the end of the chain
+// together via the specified sender. Each item passes through the chain in
+// its own goroutine, and the items are sent once every item has either
+// reached the end of the chain or been rejected. The item as it reached
+// the end of the chain, or nil if it was rejected, and the error returned
+// by the chain are returned for each item.
+func interceptItems(ctx context.Context, interceptors []Interceptor,
+	items []*ChannelItem, send interceptedSender) (*PublishResult,
+	[]*ChannelItem, []error) {
+	if len(items) == 1 {
+		arrived := make([]*ChannelItem, 1)
+		errs := make([]error, 1)
+		var result *PublishResult
+		func() {
+			defer func() {
+				if err := recover(); err != nil {
+					errs[0] = newInterceptorPanicError(err)
+				}
+			}()
+			handler := chainInterceptors(interceptors, func(
+				ctx context.Context, channel string,
+				item *pubcontrol.Item) (*PublishResult, error) {
+				if arrived[0] != nil {
+					return nil, errNextCalledTwice
+				}
+				arrived[0] = &ChannelItem{Channel: channel, Item: item}
+				var err error
+				result, err = send(ctx, arrived, nil)
+				return result, err
+			})
+			_, errs[0] = handler(ctx, items[0].Channel, items[0].Item)
+		}()
+		return result, arrived, errs
+	}
+	arrived := make([]*ChannelItem, len(items))
+	arrivedCtxs := make([]context.Context, len(items))
+	errs := make([]error, len(items))
+	decided := sync.WaitGroup{}
+	finished := sync.WaitGroup{}
+	sent := make(chan struct{})
+	var result *PublishResult
+	var sendErr error
+	for i := range items {
+		decided.Add(1)
+		finished.Add(1)
+		go func(i int) {
+			reached := false
+			defer func() {
+				if err := recover(); err != nil {
+					errs[i] = newInterceptorPanicError(err)
+				}
+				if !reached {
+					decided.Done()
+				}
+				finished.Done()
+			}()
+			handler := chainInterceptors(interceptors, func(
+				ctx context.Context, channel string,
+				item *pubcontrol.Item) (*PublishResult, error) {
+				if reached {
+					return nil, errNextCalledTwice
+				}
+				reached = true
+				arrived[i] = &ChannelItem{Channel: channel, Item: item}
+				arrivedCtxs[i] = ctx
+				decided.Done()
+				<-sent
+				return result, sendErr
+			})
+			_, errs[i] = handler(ctx, items[i].Channel, items[i].Item)
+		}(i)
+	}
+	decided.Wait()
+	sendItems := make([]*ChannelItem, 0, len(items))
+	sendCtxs := make([]context.Context, 0, len(items))
+	for i, item := range arrived {
+		if item != nil {
+			sendItems = append(sendItems, item)
+			sendCtxs = append(sendCtxs, arrivedCtxs[i])
+		}
+	}
+	if len(sendItems) > 0 {
+		result, sendErr = send(ctx, sendItems, sendCtxs)
+	} else {
+		result = &PublishResult{Endpoints: make([]*EndpointResult, 0)}
+	}
+	close(sent)
+	finished.Wait()
+	return result, arrived, errs
+}
+
+// An internal function for creating the error reported for an item whose
+// interceptor chain panicked with the specified value.
+func newInterceptorPanicError(value interface{}) error {
+	stack := make([]byte, 1024*8)
+	stack = stack[:runtime.Stack(stack, false)]
+	return &GripPublishError{err: fmt.Sprintf("PANIC: %v\n%s", value, stack)}
+}
+
+// An internal method for combining the errors returned for the items of a
+// batch, which are often the same error, into one error.
+func joinInterceptedErrors(errs []error) error {
+	unique := make([]error, 0)
+	messages := make([]string, 0)
+	for _, err := range errs {
+		if err == nil {
+			continue
+		}
+		seen := false
+		for _, other := range unique {
+			if reflect.TypeOf(err).Comparable() && other == err {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			unique = append(unique, err)
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(unique) == 0 {
+		return nil
+	} else if len(unique) == 1 {
+		return unique[0]
+	}
+	return &GripPublishError{err: strings.Join(messages, "; "),
+		errs: unique}
+}
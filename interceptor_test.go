@@ -0,0 +1,215 @@
+//    interceptor_test.go
+//    ~~~~~~~~~
+//    This module implements the interceptor tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// An internal method for creating an interceptor that rejects the items
+// published to channels with the specified prefix.
+func newTestRejectInterceptor(prefix string) Interceptor {
+	return func(ctx context.Context, channel string, item *pubcontrol.Item,
+		next PublishHandler) (*PublishResult, error) {
+		if strings.HasPrefix(channel, prefix) {
+			return nil, errors.New("rejected " + channel)
+		}
+		return next(ctx, channel, item)
+	}
+}
+
+func TestInterceptorChain(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	calls := make([]string, 0)
+	var observed *PublishResult
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		calls = append(calls, "first "+channel)
+		result, err := next(WithItemMeta(ctx, "trace", "abc"),
+			"prefix-"+channel, item)
+		observed = result
+		return result, err
+	})
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		calls = append(calls, "second "+channel)
+		return next(ctx, channel, newTestItem("replaced"))
+	})
+	result, err := gpc.PublishWithResult(WithItemMeta(context.Background(),
+		"user", "1"), "chan", newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Equal(t, observed, result)
+	assert.Equal(t, calls, []string{"first chan", "second prefix-chan"})
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "prefix-chan",
+			"meta":        map[string]interface{}{"trace": "abc", "user": "1"},
+			"http-stream": map[string]interface{}{"content": "replaced"}}})
+}
+
+func TestInterceptorReject(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(newTestRejectInterceptor("private-"))
+	err := gpc.PublishHttpStream("private-chan", "data", "", "")
+	assert.Equal(t, err.Error(), "rejected private-chan")
+	assert.Equal(t, len(requests), 0)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	assert.Equal(t, len(requests), 1)
+}
+
+func TestInterceptorBatch(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(newTestRejectInterceptor("private-"))
+	lock := sync.Mutex{}
+	observed := make([]*PublishResult, 0)
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		result, err := next(WithItemMeta(ctx, "channel", channel), channel,
+			item)
+		lock.Lock()
+		observed = append(observed, result)
+		lock.Unlock()
+		return result, err
+	})
+	err := gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "a", Item: newTestItem("1")},
+		&ChannelItem{Channel: "private-b", Item: newTestItem("2")},
+		&ChannelItem{Channel: "c", Item: newTestItem("3")}})
+	assert.Equal(t, err.Error(), "rejected private-b")
+	req := <-requests
+	assert.Equal(t, len(req.Items), 2)
+	assert.Equal(t, req.Items[0]["channel"], "a")
+	assert.Equal(t, req.Items[0]["meta"],
+		map[string]interface{}{"channel": "a"})
+	assert.Equal(t, req.Items[1]["channel"], "c")
+	assert.Equal(t, len(observed), 2)
+	assert.Equal(t, observed[0], observed[1])
+	assert.Equal(t, observed[0].Succeeded(), 1)
+}
+
+func TestInterceptorBatchPanic(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		if channel == "b" {
+			panic("boom")
+		}
+		return next(ctx, channel, item)
+	})
+	err := gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "a", Item: newTestItem("1")},
+		&ChannelItem{Channel: "b", Item: newTestItem("2")}})
+	assert.True(t, strings.HasPrefix(err.Error(), "PANIC: boom"))
+	assert.Equal(t, len((<-requests).Items), 1)
+}
+
+func TestInterceptorPanic(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		if channel == "before" {
+			panic("boom")
+		}
+		result, err := next(ctx, channel, item)
+		if result != nil {
+			panic("after")
+		}
+		return result, err
+	})
+	result, err := gpc.PublishWithResult(context.Background(), "before",
+		newTestItem("data"))
+	assert.Nil(t, result)
+	assert.True(t, strings.HasPrefix(err.Error(), "PANIC: boom"))
+	assert.Equal(t, len(requests), 0)
+	result, err = gpc.PublishWithResult(context.Background(), "chan",
+		newTestItem("data"))
+	assert.Equal(t, result.Succeeded(), 1)
+	assert.True(t, strings.HasPrefix(err.Error(), "PANIC: after"))
+	assert.Equal(t, len(requests), 1)
+}
+
+func TestInterceptorNextCalledTwice(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		next(ctx, channel, item)
+		return next(ctx, channel, item)
+	})
+	err := gpc.PublishHttpStream("chan", "data", "", "")
+	assert.Equal(t, err, errNextCalledTwice)
+	assert.Equal(t, len(requests), 1)
+}
+
+func TestInterceptorAsync(t *testing.T) {
+	server, requests := newTestSequenceServer(t, 503)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(newTestRejectInterceptor("private-"))
+	observed := make(chan *PublishResult, 1)
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		result, err := next(WithItemMeta(ctx, "async", "yes"), channel, item)
+		observed <- result
+		return result, err
+	})
+	gpc.StartAsync(&AsyncConfig{InitialBackoff: time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 2)
+	callback := func(err error) { results <- err }
+	assert.Nil(t, gpc.PublishAsync("private-chan", newTestItem("data"),
+		callback))
+	assert.Equal(t, (<-results).Error(), "rejected private-chan")
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("data"), callback))
+	assert.Nil(t, <-results)
+	result := <-observed
+	assert.Equal(t, result.Succeeded(), 1)
+	assert.Equal(t, atomic.LoadInt32(requests), int32(2))
+	assert.Equal(t, len(observed), 0)
+}
+
+func TestInterceptorAsyncMeta(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.AddInterceptor(func(ctx context.Context, channel string,
+		item *pubcontrol.Item, next PublishHandler) (*PublishResult, error) {
+		return next(WithItemMeta(ctx, "async", "yes"), "prefix-"+channel,
+			item)
+	})
+	gpc.StartAsync(nil)
+	defer gpc.Close()
+	ctx, cancel := context.WithCancel(WithItemMeta(context.Background(),
+		"user", "1"))
+	assert.Nil(t, gpc.PublishAsyncContext(ctx, "chan", newTestItem("data"),
+		nil))
+	cancel()
+	assert.Nil(t, gpc.Flush(context.Background()))
+	item := (<-requests).Items[0]
+	assert.Equal(t, item["channel"], "prefix-chan")
+	assert.Equal(t, item["meta"],
+		map[string]interface{}{"async": "yes", "user": "1"})
+}
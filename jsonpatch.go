@@ -0,0 +1,211 @@
+//    jsonpatch.go
+//    ~~~~~~~~~
+//    This module implements the JsonPatchOperation struct and features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import "bytes"
+import "encoding/json"
+import "math/big"
+import "reflect"
+import "sort"
+import "strconv"
+import "strings"
+
+// The JsonPatchOperation struct represents a single RFC 6902 JSON Patch
+// operation. A list of operations can be published as the BodyPatch of an
+// HttpResponseFormat in which case the GRIP proxy applies it to the body
+// of the held response. Instances are typically created via the
+// NewJsonPatch* methods or CreateJsonPatch.
+type JsonPatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value interface{}
+}
+
+// Create an operation that adds the specified value at the specified path.
+func NewJsonPatchAdd(path string, value interface{}) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "add", Path: path, Value: value}
+}
+
+// Create an operation that removes the value at the specified path.
+func NewJsonPatchRemove(path string) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "remove", Path: path}
+}
+
+// Create an operation that replaces the value at the specified path.
+func NewJsonPatchReplace(path string,
+	value interface{}) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "replace", Path: path, Value: value}
+}
+
+// Create an operation that moves the value at the 'from' path to the
+// specified path.
+func NewJsonPatchMove(from, path string) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "move", Path: path, From: from}
+}
+
+// Create an operation that copies the value at the 'from' path to the
+// specified path.
+func NewJsonPatchCopy(from, path string) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "copy", Path: path, From: from}
+}
+
+// Create an operation that tests that the value at the specified path is
+// equal to the specified value.
+func NewJsonPatchTest(path string, value interface{}) *JsonPatchOperation {
+	return &JsonPatchOperation{Op: "test", Path: path, Value: value}
+}
+
+// Export the operation into the RFC 6902 format and include only the
+// fields that apply to the type of operation.
+func (op *JsonPatchOperation) Export() map[string]interface{} {
+	export := make(map[string]interface{})
+	export["op"] = op.Op
+	export["path"] = op.Path
+	switch op.Op {
+	case "add", "replace", "test":
+		export["value"] = op.Value
+	case "move", "copy":
+		export["from"] = op.From
+	}
+	return export
+}
+
+// Create the list of JSON Patch operations that transforms the specified
+// old value into the specified new value. Both values are first converted
+// to their JSON representation, so any value that can be marshaled to JSON
+// is accepted. Objects and arrays are compared member by member while all
+// other differences result in a replace operation. Numbers are compared
+// exactly rather than as float64 values, so that changes to large integers
+// are not lost, and appear in the values of the operations as json.Number.
+func CreateJsonPatch(oldValue,
+	newValue interface{}) ([]*JsonPatchOperation, error) {
+	oldJson, err := normalizeJson(oldValue)
+	if err != nil {
+		return nil, err
+	}
+	newJson, err := normalizeJson(newValue)
+	if err != nil {
+		return nil, err
+	}
+	return diffJson("", oldJson, newJson, make([]*JsonPatchOperation, 0)),
+		nil
+}
+
+// An internal method for converting a value into the generic form produced
+// by unmarshaling its JSON representation. Numbers are kept as json.Number
+// so that they do not lose precision.
+func normalizeJson(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var out interface{}
+	err = decoder.Decode(&out)
+	return out, err
+}
+
+// An internal method for determining whether the specified JSON numbers are
+// equal. Numbers with the same text are equal, and numbers written
+// differently, such as 1 and 1.0, are compared by their exact value.
+func equalJsonNumbers(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+	aValue, aOk := new(big.Rat).SetString(string(a))
+	bValue, bOk := new(big.Rat).SetString(string(b))
+	return aOk && bOk && aValue.Cmp(bValue) == 0
+}
+
+// An internal method for appending the operations that transform the old
+// value at the specified path into the new value.
+func diffJson(path string, oldValue, newValue interface{},
+	ops []*JsonPatchOperation) []*JsonPatchOperation {
+	switch oldTyped := oldValue.(type) {
+	case map[string]interface{}:
+		if newTyped, ok := newValue.(map[string]interface{}); ok {
+			return diffJsonObject(path, oldTyped, newTyped, ops)
+		}
+	case []interface{}:
+		if newTyped, ok := newValue.([]interface{}); ok {
+			return diffJsonArray(path, oldTyped, newTyped, ops)
+		}
+	case json.Number:
+		if newTyped, ok := newValue.(json.Number); ok {
+			if !equalJsonNumbers(oldTyped, newTyped) {
+				ops = append(ops, NewJsonPatchReplace(path, newValue))
+			}
+			return ops
+		}
+	}
+	if !reflect.DeepEqual(oldValue, newValue) {
+		ops = append(ops, NewJsonPatchReplace(path, newValue))
+	}
+	return ops
+}
+
+// An internal method for appending the operations that transform the old
+// object at the specified path into the new object. Keys are processed in
+// sorted order so that the resulting patch is deterministic.
+func diffJsonObject(path string, oldValue, newValue map[string]interface{},
+	ops []*JsonPatchOperation) []*JsonPatchOperation {
+	keys := make([]string, 0, len(oldValue)+len(newValue))
+	for key := range oldValue {
+		keys = append(keys, key)
+	}
+	for key := range newValue {
+		if _, ok := oldValue[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		keyPath := path + "/" + escapeJsonPointer(key)
+		oldMember, inOld := oldValue[key]
+		newMember, inNew := newValue[key]
+		if !inNew {
+			ops = append(ops, NewJsonPatchRemove(keyPath))
+		} else if !inOld {
+			ops = append(ops, NewJsonPatchAdd(keyPath, newMember))
+		} else {
+			ops = diffJson(keyPath, oldMember, newMember, ops)
+		}
+	}
+	return ops
+}
+
+// An internal method for appending the operations that transform the old
+// array at the specified path into the new array. Common elements are
+// compared by index, surplus elements are removed from the end and new
+// elements are appended.
+func diffJsonArray(path string, oldValue, newValue []interface{},
+	ops []*JsonPatchOperation) []*JsonPatchOperation {
+	common := len(oldValue)
+	if len(newValue) < common {
+		common = len(newValue)
+	}
+	for i := 0; i < common; i++ {
+		ops = diffJson(path+"/"+strconv.Itoa(i), oldValue[i], newValue[i],
+			ops)
+	}
+	for i := len(oldValue) - 1; i >= common; i-- {
+		ops = append(ops, NewJsonPatchRemove(path+"/"+strconv.Itoa(i)))
+	}
+	for i := common; i < len(newValue); i++ {
+		ops = append(ops, NewJsonPatchAdd(path+"/"+strconv.Itoa(i),
+			newValue[i]))
+	}
+	return ops
+}
+
+// An internal method for escaping a key for use in a JSON Pointer.
+func escapeJsonPointer(key string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
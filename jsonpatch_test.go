@@ -0,0 +1,89 @@
+//    jsonpatch_test.go
+//    ~~~~~~~~~
+//    This module implements the JsonPatchOperation tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestJsonPatchOperationExport(t *testing.T) {
+	assert.Equal(t, NewJsonPatchAdd("/a", 1).Export(),
+		map[string]interface{}{"op": "add", "path": "/a", "value": 1})
+	assert.Equal(t, NewJsonPatchRemove("/a").Export(),
+		map[string]interface{}{"op": "remove", "path": "/a"})
+	assert.Equal(t, NewJsonPatchReplace("/a", nil).Export(),
+		map[string]interface{}{"op": "replace", "path": "/a", "value": nil})
+	assert.Equal(t, NewJsonPatchMove("/a", "/b").Export(),
+		map[string]interface{}{"op": "move", "path": "/b", "from": "/a"})
+	assert.Equal(t, NewJsonPatchCopy("/a", "/b").Export(),
+		map[string]interface{}{"op": "copy", "path": "/b", "from": "/a"})
+	assert.Equal(t, NewJsonPatchTest("/a", "x").Export(),
+		map[string]interface{}{"op": "test", "path": "/a", "value": "x"})
+}
+
+func TestCreateJsonPatch(t *testing.T) {
+	patch, err := CreateJsonPatch(
+		map[string]interface{}{"same": 1, "changed": "a", "removed": true,
+			"nested": map[string]interface{}{"a/b": 1, "c~d": 2}},
+		map[string]interface{}{"same": 1, "changed": "b", "added": nil,
+			"nested": map[string]interface{}{"a/b": 3, "c~d": 2}})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchAdd("/added", nil),
+		NewJsonPatchReplace("/changed", "b"),
+		NewJsonPatchReplace("/nested/a~1b", json.Number("3")),
+		NewJsonPatchRemove("/removed")})
+	patch, err = CreateJsonPatch([]int{1, 2, 3, 4}, []int{1, 5})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchReplace("/1", json.Number("5")),
+		NewJsonPatchRemove("/3"),
+		NewJsonPatchRemove("/2")})
+	patch, err = CreateJsonPatch([]string{"a"}, []string{"a", "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchAdd("/1", "b")})
+	patch, err = CreateJsonPatch([]string{"a"}, map[string]string{"a": "b"})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchReplace("", map[string]interface{}{"a": "b"})})
+	type doc struct {
+		Count int `json:"count"`
+	}
+	patch, err = CreateJsonPatch(&doc{1}, &doc{1})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{})
+	patch, err = CreateJsonPatch(map[string]int64{"id": 9007199254740993},
+		map[string]int64{"id": 9007199254740992})
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchReplace("/id", json.Number("9007199254740992"))})
+	data, err := json.Marshal(patch[0].Export())
+	assert.Nil(t, err)
+	assert.Equal(t, string(data),
+		`{"op":"replace","path":"/id","value":9007199254740992}`)
+	patch, err = CreateJsonPatch(json.RawMessage(`{"id":18446744073709551617}`),
+		json.RawMessage(`{"id":18446744073709551616}`))
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchReplace("/id", json.Number("18446744073709551616"))})
+	patch, err = CreateJsonPatch(json.RawMessage(`0.1000000000000000000001`),
+		json.RawMessage(`0.1000000000000000000002`))
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{
+		NewJsonPatchReplace("", json.Number("0.1000000000000000000002"))})
+	patch, err = CreateJsonPatch(json.RawMessage(`[1, 1e2]`),
+		json.RawMessage(`[1.0, 100]`))
+	assert.Nil(t, err)
+	assert.Equal(t, patch, []*JsonPatchOperation{})
+	patch, err = CreateJsonPatch(make(chan int), nil)
+	assert.Nil(t, patch)
+	assert.NotNil(t, err)
+}
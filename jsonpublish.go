@@ -0,0 +1,61 @@
+//    jsonpublish.go
+//    ~~~~~~~~~
+//    This module implements the typed JSON publishing features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/fanout/go-pubcontrol"
+)
+
+// The content type set on the HTTP response format of JSON items.
+const JSONContentType = "application/json"
+
+// Marshal the specified value to JSON and return an item containing an
+// HTTP response format with the JSON as its body and its Content-Type
+// header set, an HTTP stream format with the JSON followed by a newline
+// delimiter, and a WebSocket text message with the JSON as its content. The
+// value is marshaled once and the encoding is shared by all of the formats.
+func NewJSONItem[T any](value T, id, prevId string) (*pubcontrol.Item,
+	error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	content := make([]byte, len(body)+1)
+	copy(content, body)
+	content[len(body)] = '\n'
+	return pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpResponseFormat{Headers: map[string]string{
+			"Content-Type": JSONContentType}, Body: body},
+		&HttpStreamFormat{Content: content},
+		&WebSocketMessageFormat{Content: body}},
+		id, prevId), nil
+}
+
+// Publish the specified value as JSON via the specified publisher, such as
+// a GripPubControl instance, with a specified channel and optional ID and
+// previous ID. The item published is the one returned by NewJSONItem, so
+// that HTTP response, HTTP stream and WebSocket subscribers all receive the
+// value.
+func PublishJSON[T any](pub Publisher, channel string, value T, id,
+	prevId string) error {
+	return PublishJSONContext(context.Background(), pub, channel, value, id,
+		prevId)
+}
+
+// The same as PublishJSON except that the publish requests are canceled
+// when the specified context is canceled or its deadline is exceeded.
+func PublishJSONContext[T any](ctx context.Context, pub Publisher,
+	channel string, value T, id, prevId string) error {
+	item, err := NewJSONItem(value, id, prevId)
+	if err != nil {
+		return err
+	}
+	return pub.PublishContext(ctx, channel, item)
+}
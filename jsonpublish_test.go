@@ -0,0 +1,56 @@
+//    jsonpublish_test.go
+//    ~~~~~~~~~
+//    This module implements the typed JSON publishing tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type testJSONEvent struct {
+	Type  string `json:"type"`
+	Count int    `json:"count"`
+}
+
+func TestNewJSONItem(t *testing.T) {
+	item, err := NewJSONItem(testJSONEvent{Type: "update", Count: 2},
+		"id", "prev-id")
+	assert.Nil(t, err)
+	body := []byte(`{"type":"update","count":2}`)
+	assert.Equal(t, item, pubcontrol.NewItem([]pubcontrol.Formatter{
+		&HttpResponseFormat{Headers: map[string]string{
+			"Content-Type": "application/json"}, Body: body},
+		&HttpStreamFormat{Content: append(body, '\n')},
+		&WebSocketMessageFormat{Content: body}}, "id", "prev-id"))
+	item, err = NewJSONItem(make(chan int), "", "")
+	assert.Nil(t, item)
+	assert.NotNil(t, err)
+}
+
+func TestPublishJSON(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	assert.Nil(t, PublishJSON(gpc, "chan", []string{"a", "b"}, "id",
+		"prev-id"))
+	assert.Equal(t, (<-requests).Items, []map[string]interface{}{
+		map[string]interface{}{"channel": "chan", "id": "id",
+			"prev-id": "prev-id",
+			"http-response": map[string]interface{}{
+				"headers": map[string]interface{}{
+					"Content-Type": "application/json"},
+				"body": `["a","b"]`},
+			"http-stream": map[string]interface{}{"content": "[\"a\",\"b\"]\n"},
+			"ws-message":  map[string]interface{}{"content": `["a","b"]`}}})
+	assert.NotNil(t, PublishJSON(gpc, "chan", func() {}, "", ""))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.NotNil(t, PublishJSONContext(ctx, gpc, "chan", 1, "", ""))
+}
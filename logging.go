@@ -0,0 +1,98 @@
+//    logging.go
+//    ~~~~~~~~~
+//    This module implements the structured logging features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// The attribute keys used consistently by the log records of this package.
+const (
+	LogKeyChannel      = "channel"
+	LogKeyEndpoint     = "endpoint"
+	LogKeyEndpointName = "endpoint_name"
+	LogKeyConnectionId = "connection_id"
+)
+
+// The logger used by the package-level functions.
+var packageLogger atomic.Pointer[slog.Logger]
+
+// The logger used when no logger has been set, which discards all records.
+var discardLogger = slog.New(discardHandler{})
+
+// Set the logger used by the package-level functions such as ValidateSig,
+// CreateHold and the WebSocket-over-HTTP helpers, as well as by
+// GripPubControl instances that have no logger of their own. Nothing is
+// logged by default, and setting a nil logger disables logging again.
+func SetLogger(logger *slog.Logger) {
+	packageLogger.Store(logger)
+}
+
+// An internal method for returning the logger used by the package-level
+// functions.
+func getLogger() *slog.Logger {
+	if logger := packageLogger.Load(); logger != nil {
+		return logger
+	}
+	return discardLogger
+}
+
+// Set the logger used by this GripPubControl instance for recording each
+// publish request, retries and failures. If no logger is set then the
+// logger set via the package-level SetLogger function is used.
+func (gpc *GripPubControl) SetLogger(logger *slog.Logger) {
+	gpc.logger.Store(logger)
+}
+
+// An internal method for returning the logger of this GripPubControl
+// instance. The logger is held atomically rather than under the settings
+// lock so that it can be used while the settings lock is held.
+func (gpc *GripPubControl) getLogger() *slog.Logger {
+	if logger := gpc.logger.Load(); logger != nil {
+		return logger
+	}
+	return getLogger()
+}
+
+// An internal method for returning the attributes identifying the
+// specified client.
+func endpointLogAttrs(gpcc *GripPubControlClient) []any {
+	attrs := []any{slog.String(LogKeyEndpoint, gpcc.uri)}
+	if name := gpcc.Name(); name != "" {
+		attrs = append(attrs, slog.String(LogKeyEndpointName, name))
+	}
+	return attrs
+}
+
+// An internal method for returning the channel attribute of the specified
+// channels, which are joined by commas if there are several.
+func channelLogAttr(channels []string) slog.Attr {
+	return slog.String(LogKeyChannel, strings.Join(channels, ","))
+}
+
+// An internal slog.Handler that discards all records.
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool {
+	return false
+}
+
+func (discardHandler) Handle(context.Context, slog.Record) error {
+	return nil
+}
+
+func (h discardHandler) WithAttrs([]slog.Attr) slog.Handler {
+	return h
+}
+
+func (h discardHandler) WithGroup(string) slog.Handler {
+	return h
+}
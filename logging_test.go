@@ -0,0 +1,154 @@
+//    logging_test.go
+//    ~~~~~~~~~
+//    This module implements the structured logging tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// An internal struct used to capture the records of a JSON logger.
+type testLogBuffer struct {
+	lock   sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *testLogBuffer) Write(p []byte) (int, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.buffer.Write(p)
+}
+
+// An internal method for returning the captured records.
+func (b *testLogBuffer) records() []map[string]interface{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	records := make([]map[string]interface{}, 0)
+	for _, line := range strings.Split(b.buffer.String(), "\n") {
+		if line != "" {
+			record := make(map[string]interface{})
+			json.Unmarshal([]byte(line), &record)
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// An internal method for returning the records with the specified message.
+func (b *testLogBuffer) find(msg string) []map[string]interface{} {
+	found := make([]map[string]interface{}, 0)
+	for _, record := range b.records() {
+		if record["msg"] == msg {
+			found = append(found, record)
+		}
+	}
+	return found
+}
+
+// An internal method for creating a logger that captures debug records.
+func newTestLogger() (*slog.Logger, *testLogBuffer) {
+	buffer := &testLogBuffer{}
+	return slog.New(slog.NewJSONHandler(buffer,
+		&slog.HandlerOptions{Level: slog.LevelDebug})), buffer
+}
+
+func TestGripPubControlSetLogger(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 200, 500)
+	gpc.clients[0].SetName("ok")
+	logger, buffer := newTestLogger()
+	gpc.SetLogger(logger)
+	assert.NotNil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+	published := buffer.find("published to endpoint")
+	assert.Equal(t, len(published), 1)
+	assert.Equal(t, published[0]["level"], "DEBUG")
+	assert.Equal(t, published[0][LogKeyChannel], "chan")
+	assert.Equal(t, published[0][LogKeyEndpoint], gpc.clients[0].Uri())
+	assert.Equal(t, published[0][LogKeyEndpointName], "ok")
+	assert.Equal(t, published[0]["status"], float64(200))
+	failed := buffer.find("publish to endpoint failed")
+	assert.Equal(t, len(failed), 1)
+	assert.Equal(t, failed[0]["level"], "WARN")
+	assert.Equal(t, failed[0][LogKeyEndpoint], gpc.clients[1].Uri())
+	assert.Nil(t, failed[0][LogKeyEndpointName])
+	assert.Equal(t, failed[0]["status"], float64(500))
+	assert.NotNil(t, failed[0]["error"])
+	failed = buffer.find("publish failed")
+	assert.Equal(t, len(failed), 1)
+	assert.Equal(t, failed[0]["level"], "DEBUG")
+	assert.Equal(t, failed[0][LogKeyChannel], "chan")
+}
+
+func TestPackageSetLogger(t *testing.T) {
+	logger, buffer := newTestLogger()
+	SetLogger(logger)
+	t.Cleanup(func() { SetLogger(nil) })
+	assert.False(t, ValidateSig("token", "key"))
+	assert.Equal(t, buffer.find("invalid GRIP signature")[0]["level"],
+		"DEBUG")
+	_, err := CreateHoldStream([]*Channel{&Channel{Name: "a"},
+		&Channel{Name: "b"}}, nil)
+	assert.Nil(t, err)
+	hold := buffer.find("created hold")[0]
+	assert.Equal(t, hold["mode"], "stream")
+	assert.Equal(t, hold[LogKeyChannel], "a,b")
+	_, err = CreateHoldResponse([]*Channel{&Channel{Name: "a"}}, 1, nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, buffer.find("failed to create hold")[0]["level"],
+		"DEBUG")
+
+	gpc, _ := newTestPolicyPubControl(t, 200)
+	gpc.PublishHttpStream("chan", "data", "", "")
+	assert.Equal(t, len(buffer.find("published to endpoint")), 1)
+}
+
+func TestDecodeWebSocketRequest(t *testing.T) {
+	logger, buffer := newTestLogger()
+	SetLogger(logger)
+	t.Cleanup(func() { SetLogger(nil) })
+	r, _ := http.NewRequest("POST", "http://localhost/",
+		strings.NewReader("OPEN\r\nTEXT 5\r\nHello\r\n"))
+	r.Header.Set("Connection-Id", "conn-1")
+	connectionId, events, err := DecodeWebSocketRequest(r)
+	assert.Nil(t, err)
+	assert.Equal(t, connectionId, "conn-1")
+	assert.Equal(t, events, []*WebSocketEvent{&WebSocketEvent{Type: "OPEN"},
+		&WebSocketEvent{Type: "TEXT", Content: "Hello"}})
+	received := buffer.find("received WebSocket event")
+	assert.Equal(t, len(received), 2)
+	assert.Equal(t, received[1][LogKeyConnectionId], "conn-1")
+	assert.Equal(t, received[1]["type"], "TEXT")
+	r, _ = http.NewRequest("POST", "http://localhost/",
+		strings.NewReader("OPEN"))
+	r.Header.Set("Connection-Id", "conn-2")
+	connectionId, _, err = DecodeWebSocketRequest(r)
+	assert.NotNil(t, err)
+	assert.Equal(t, connectionId, "conn-2")
+	failed := buffer.find("failed to decode WebSocket events")
+	assert.Equal(t, len(failed), 1)
+	assert.Equal(t, failed[0]["level"], "DEBUG")
+	assert.Equal(t, failed[0][LogKeyConnectionId], "conn-2")
+	_, err = DecodeWebSocketEvents("OPEN")
+	assert.NotNil(t, err)
+	assert.Equal(t, len(buffer.find("failed to decode WebSocket events")),
+		2)
+	for _, record := range buffer.records() {
+		assert.NotEqual(t, record["level"], "ERROR")
+	}
+}
+
+func TestDiscardLogger(t *testing.T) {
+	SetLogger(nil)
+	assert.Equal(t, getLogger(), discardLogger)
+	assert.False(t, ValidateSig("token", "key"))
+}
@@ -0,0 +1,129 @@
+//    observer.go
+//    ~~~~~~~~~
+//    This module implements the PublishObserver interface and the expvar
+//    based observer.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"expvar"
+	"sync"
+	"time"
+)
+
+// The PublishEvent struct describes a single publish request made to a
+// single endpoint. The channels and item count are set when the request
+// starts, while the remaining fields are set when it finishes. Bytes is
+// the size of the request body and is zero for wrapped PubControlClient
+// instances.
+type PublishEvent struct {
+	Uri        string
+	Name       string
+	Channels   []string
+	Items      int
+	Bytes      int
+	StatusCode int
+	Duration   time.Duration
+	Err        error
+}
+
+// The PublishObserver interface is used to monitor the publish requests
+// made by GripPubControl, such as for collecting metrics or tracing. The
+// methods are called from the goroutine that publishes to the endpoint and
+// must therefore be safe for concurrent use. Only publishing is observed:
+// creating hold instructions makes no requests and is not reported to the
+// observer, although it is recorded in the debug log records.
+type PublishObserver interface {
+
+	// Called before the publish request is made. The returned context is
+	// used for the request and passed to PublishFinished, which allows a
+	// span to be attached to it.
+	PublishStarted(ctx context.Context, event *PublishEvent) context.Context
+
+	// Called with the same event after the publish request has finished.
+	PublishFinished(ctx context.Context, event *PublishEvent)
+}
+
+// Set the observer that is notified of each publish request made to each
+// endpoint. Setting a nil observer disables the notifications.
+func (gpc *GripPubControl) SetObserver(observer PublishObserver) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	gpc.observer = observer
+}
+
+// An internal method for returning the observer.
+func (gpc *GripPubControl) getObserver() PublishObserver {
+	gpc.settingsRWLock.RLock()
+	defer gpc.settingsRWLock.RUnlock()
+	return gpc.observer
+}
+
+// An internal struct implementing a PublishObserver that publishes its
+// counters via expvar.
+type expvarObserver struct {
+	vars      *expvar.Map
+	endpoints *expvar.Map
+	lock      sync.Mutex
+}
+
+// Create a PublishObserver that publishes counters under the specified
+// expvar name, which makes them available at /debug/vars. The counters are
+// 'requests', 'failures', 'in_flight', 'items', 'bytes' and 'duration_ns',
+// and 'endpoints' holds the same counters, other than 'in_flight', for
+// each endpoint keyed by its name or, for anonymous endpoints, its URI.
+// As with expvar.NewMap, this function panics if the name is already in
+// use.
+func NewExpvarObserver(name string) PublishObserver {
+	observer := &expvarObserver{vars: expvar.NewMap(name),
+		endpoints: new(expvar.Map).Init()}
+	observer.vars.Set("endpoints", observer.endpoints)
+	return observer
+}
+
+// Count the request as in flight.
+func (observer *expvarObserver) PublishStarted(ctx context.Context,
+	event *PublishEvent) context.Context {
+	observer.vars.Add("in_flight", 1)
+	return ctx
+}
+
+// Add the outcome of the request to the totals and to the counters of the
+// endpoint.
+func (observer *expvarObserver) PublishFinished(ctx context.Context,
+	event *PublishEvent) {
+	observer.vars.Add("in_flight", -1)
+	key := event.Name
+	if key == "" {
+		key = event.Uri
+	}
+	for _, vars := range []*expvar.Map{observer.vars,
+		observer.getEndpoint(key)} {
+		vars.Add("requests", 1)
+		vars.Add("items", int64(event.Items))
+		vars.Add("bytes", int64(event.Bytes))
+		vars.Add("duration_ns", int64(event.Duration))
+		if event.Err != nil {
+			vars.Add("failures", 1)
+		} else {
+			vars.Add("failures", 0)
+		}
+	}
+}
+
+// An internal method for returning the counters of the endpoint with the
+// specified key, creating them if necessary.
+func (observer *expvarObserver) getEndpoint(key string) *expvar.Map {
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	if vars, ok := observer.endpoints.Get(key).(*expvar.Map); ok {
+		return vars
+	}
+	vars := new(expvar.Map).Init()
+	observer.endpoints.Set(key, vars)
+	return vars
+}
@@ -0,0 +1,103 @@
+//    observer_test.go
+//    ~~~~~~~~~
+//    This module implements the PublishObserver tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+)
+
+// An internal type used as the key of the test observer context value.
+type testObserverKey struct{}
+
+// An internal struct implementing a PublishObserver that records events.
+type testObserver struct {
+	lock     sync.Mutex
+	started  []PublishEvent
+	finished []*PublishEvent
+	marked   bool
+}
+
+func (observer *testObserver) PublishStarted(ctx context.Context,
+	event *PublishEvent) context.Context {
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	observer.started = append(observer.started, *event)
+	return context.WithValue(ctx, testObserverKey{}, event)
+}
+
+func (observer *testObserver) PublishFinished(ctx context.Context,
+	event *PublishEvent) {
+	observer.lock.Lock()
+	defer observer.lock.Unlock()
+	observer.marked = ctx.Value(testObserverKey{}) == event
+	observer.finished = append(observer.finished, event)
+}
+
+func TestSetObserver(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 200, 500)
+	gpc.clients[0].SetName("ok")
+	observer := &testObserver{}
+	gpc.SetObserver(observer)
+	err := gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "a", Item: newTestItem("1")},
+		&ChannelItem{Channel: "b", Item: newTestItem("2")},
+		&ChannelItem{Channel: "a", Item: newTestItem("3")}})
+	assert.NotNil(t, err)
+	assert.Equal(t, len(observer.started), 2)
+	assert.Equal(t, len(observer.finished), 2)
+	assert.True(t, observer.marked)
+	for _, event := range observer.started {
+		assert.Equal(t, event.Channels, []string{"a", "b"})
+		assert.Equal(t, event.Items, 3)
+		assert.Equal(t, event.Bytes, 0)
+		assert.Nil(t, event.Err)
+	}
+	for _, event := range observer.finished {
+		assert.True(t, event.Bytes > 0)
+		assert.True(t, event.Duration > 0)
+		if event.Uri == gpc.clients[0].Uri() {
+			assert.Equal(t, event.Name, "ok")
+			assert.Equal(t, event.StatusCode, 200)
+			assert.Nil(t, event.Err)
+		} else {
+			assert.Equal(t, event.StatusCode, 500)
+			assert.NotNil(t, event.Err)
+		}
+	}
+	gpc.SetObserver(nil)
+	gpc.PublishHttpStream("a", "data", "", "")
+	assert.Equal(t, len(observer.finished), 2)
+}
+
+func TestExpvarObserver(t *testing.T) {
+	gpc, _ := newTestPolicyPubControl(t, 200, 500)
+	gpc.clients[0].SetName("ok")
+	gpc.SetObserver(NewExpvarObserver("gripcontrol_test_publishes"))
+	gpc.PublishHttpStream("a", "data", "", "")
+	gpc.PublishHttpStream("a", "data", "", "")
+	var vars map[string]interface{}
+	assert.Nil(t, json.Unmarshal([]byte(
+		expvar.Get("gripcontrol_test_publishes").String()), &vars))
+	assert.Equal(t, vars["requests"], float64(4))
+	assert.Equal(t, vars["failures"], float64(2))
+	assert.Equal(t, vars["items"], float64(4))
+	assert.Equal(t, vars["in_flight"], float64(0))
+	assert.True(t, vars["bytes"].(float64) > 0)
+	endpoints := vars["endpoints"].(map[string]interface{})
+	assert.Equal(t, len(endpoints), 2)
+	ok := endpoints["ok"].(map[string]interface{})
+	assert.Equal(t, ok["requests"], float64(2))
+	assert.Equal(t, ok["failures"], float64(0))
+	failing := endpoints[gpc.clients[1].Uri()].(map[string]interface{})
+	assert.Equal(t, failing["failures"], float64(2))
+}
@@ -0,0 +1,241 @@
+//    outbox.go
+//    ~~~~~~~~~
+//    This module implements the durable outbox used for asynchronous
+//    publishing.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sort"
+	"sync"
+)
+
+// The error returned by OpenOutbox when asynchronous publishing is already
+// running.
+var ErrAsyncRunning = &GripPublishError{err: "asynchronous publishing " +
+	"is already running"}
+
+// An internal struct representing a single line of the outbox file. An
+// 'add' record holds the exported items and publish tags of a publish and
+// is written again with Prepared set once the items have been sequenced,
+// and an 'ack' record marks the publish as complete.
+type outboxRecord struct {
+	Op       string                   `json:"op"`
+	Seq      uint64                   `json:"seq"`
+	Items    []map[string]interface{} `json:"items,omitempty"`
+	Tags     []string                 `json:"tags,omitempty"`
+	Prepared bool                     `json:"prepared,omitempty"`
+}
+
+// An internal struct that owns the outbox write-ahead log file.
+type outbox struct {
+	lock    sync.Mutex
+	file    *os.File
+	nextSeq uint64
+	pending map[uint64]bool
+	replay  []*outboxRecord
+}
+
+// Open the outbox write-ahead log at the specified path, creating it if
+// necessary. While an outbox is open, each item passed to PublishAsync is
+// appended to the file before it is queued and is removed once it has been
+// published to the endpoints required by the delivery policy or has failed
+// with an error that retrying cannot fix. Items that were still pending when
+// the process stopped are published again when StartAsync is called, so
+// delivery is at least once. The outbox must be opened before StartAsync
+// is called and is closed by Close.
+func (gpc *GripPubControl) OpenOutbox(path string) error {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	if gpc.async != nil {
+		return ErrAsyncRunning
+	}
+	ob, err := openOutbox(path)
+	if err != nil {
+		return err
+	}
+	if gpc.outbox != nil {
+		gpc.outbox.close()
+	}
+	gpc.outbox = ob
+	return nil
+}
+
+// An internal method for opening the outbox at the specified path. The
+// records that are still pending are kept for replaying and the file is
+// rewritten to contain only those records.
+func openOutbox(path string) (*outbox, error) {
+	records, err := readOutboxRecords(path)
+	if err != nil {
+		return nil, err
+	}
+	ob := &outbox{pending: make(map[uint64]bool),
+		replay: make([]*outboxRecord, 0, len(records))}
+	for _, record := range records {
+		ob.replay = append(ob.replay, record)
+		ob.pending[record.Seq] = true
+		if record.Seq >= ob.nextSeq {
+			ob.nextSeq = record.Seq + 1
+		}
+	}
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY,
+		0600)
+	if err != nil {
+		return nil, err
+	}
+	writer := bufio.NewWriter(tmp)
+	for _, record := range records {
+		if err = writeOutboxRecord(writer, record); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	tmp.Close()
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return nil, err
+	}
+	ob.file, err = os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return ob, nil
+}
+
+// An internal method for reading the pending records of the outbox file at
+// the specified path, ordered by their sequence number. A missing file has
+// no records, and a partially written last line, as left behind by a
+// crash, is ignored.
+func readOutboxRecords(path string) ([]*outboxRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	latest := make(map[uint64]*outboxRecord)
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		record := &outboxRecord{}
+		if json.Unmarshal(line, record) != nil {
+			break
+		}
+		if record.Op == "ack" {
+			delete(latest, record.Seq)
+		} else {
+			latest[record.Seq] = record
+		}
+	}
+	records := make([]*outboxRecord, 0, len(latest))
+	for _, record := range latest {
+		records = append(records, record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Seq < records[j].Seq
+	})
+	return records, nil
+}
+
+// An internal method for writing the specified record as a single line.
+func writeOutboxRecord(writer io.Writer, record *outboxRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(append(line, '\n'))
+	return err
+}
+
+// An internal method for appending the specified record to the file and
+// syncing it to disk. The lock must be held by the caller.
+func (ob *outbox) write(record *outboxRecord) error {
+	if err := writeOutboxRecord(ob.file, record); err != nil {
+		return err
+	}
+	return ob.file.Sync()
+}
+
+// An internal method for adding the specified exported items and publish
+// tags to the outbox and returning their sequence number.
+func (ob *outbox) add(exports []map[string]interface{},
+	tags []string) (uint64, error) {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+	seq := ob.nextSeq
+	if err := ob.write(&outboxRecord{Op: "add", Seq: seq, Items: exports,
+		Tags: tags}); err != nil {
+		return 0, err
+	}
+	ob.nextSeq++
+	ob.pending[seq] = true
+	return seq, nil
+}
+
+// An internal method for recording that the items with the specified
+// sequence number have been prepared, so that they are not sequenced again
+// when replayed.
+func (ob *outbox) prepared(seq uint64, exports []map[string]interface{},
+	tags []string) error {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+	return ob.write(&outboxRecord{Op: "add", Seq: seq, Items: exports,
+		Tags: tags, Prepared: true})
+}
+
+// An internal method for removing the items with the specified sequence
+// number from the outbox. The file is truncated once nothing is pending.
+func (ob *outbox) ack(seq uint64) error {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+	delete(ob.pending, seq)
+	if len(ob.pending) == 0 {
+		if err := ob.file.Truncate(0); err != nil {
+			return err
+		}
+		return ob.file.Sync()
+	}
+	return ob.write(&outboxRecord{Op: "ack", Seq: seq})
+}
+
+// An internal method for closing the outbox file.
+func (ob *outbox) close() error {
+	ob.lock.Lock()
+	defer ob.lock.Unlock()
+	return ob.file.Close()
+}
+
+// An internal method for converting the specified outbox record back into
+// an asynchronous publish request.
+func (record *outboxRecord) asyncRequest() *asyncRequest {
+	items := make([]*ChannelItem, 0, len(record.Items))
+	for _, export := range record.Items {
+		items = append(items, exportedChannelItem(export))
+	}
+	return &asyncRequest{items: items, exports: record.Items,
+		tags: record.Tags, seq: record.Seq, durable: true,
+		prepared: record.Prepared}
+}
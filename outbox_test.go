@@ -0,0 +1,192 @@
+//    outbox_test.go
+//    ~~~~~~~~~
+//    This module implements the outbox tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// An internal method for creating a GripPubControl instance that publishes
+// to a test server responding with the specified status code and that has
+// the outbox at the specified path open.
+func newTestOutboxPubControl(t *testing.T, path string,
+	code int) (*GripPubControl, chan *testPublishRequest) {
+	server, requests := newTestPublishServer(t, code)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	assert.Nil(t, gpc.OpenOutbox(path))
+	gpc.StartAsync(&AsyncConfig{MaxRetries: -1})
+	return gpc, requests
+}
+
+func TestOutboxReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	gpc, requests := newTestOutboxPubControl(t, path, 503)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), nil))
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("2"), nil))
+	assert.Nil(t, gpc.Close())
+	assert.Equal(t, len(requests), 2)
+	records, err := readOutboxRecords(path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(records), 2)
+
+	gpc, requests = newTestOutboxPubControl(t, path, 200)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("3"), nil))
+	assert.Nil(t, gpc.Flush(context.Background()))
+	for _, content := range []string{"1", "2", "3"} {
+		req := <-requests
+		assert.Equal(t, req.Items[0]["channel"], "chan")
+		assert.Equal(t, req.Items[0]["http-stream"],
+			map[string]interface{}{"content": content})
+	}
+	assert.Nil(t, gpc.Close())
+	info, err := os.Stat(path)
+	assert.Nil(t, err)
+	assert.Equal(t, info.Size(), int64(0))
+}
+
+func TestOutboxReplayTags(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	gpc, requests := newTestOutboxPubControl(t, path, 503)
+	gpc.clients[0].SetTags("eu")
+	assert.Nil(t, gpc.PublishAsyncContext(WithPublishTags(
+		context.Background(), "eu"), "chan", newTestItem("1"), nil))
+	assert.Nil(t, gpc.Close())
+	records, err := readOutboxRecords(path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].Tags, []string{"eu"})
+	assert.True(t, records[0].Prepared)
+	assert.Equal(t, len(requests), 1)
+
+	gpc, replayRequests := newTestPolicyPubControl(t, 200, 200)
+	gpc.clients[0].SetTags("eu")
+	assert.Nil(t, gpc.OpenOutbox(path))
+	gpc.StartAsync(nil)
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Nil(t, gpc.Close())
+	assert.Equal(t, len(replayRequests[0]), 1)
+	assert.Equal(t, len(replayRequests[1]), 0)
+}
+
+func TestOutboxRedelivery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	okServer, okRequests := newTestPublishServer(t, 200)
+	flakyServer, calls := newTestSequenceServer(t, 503, 503)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(okServer.URL))
+	gpc.AddGripClient(NewGripPubControlClient(flakyServer.URL))
+	assert.Nil(t, gpc.OpenOutbox(path))
+	gpc.StartAsync(&AsyncConfig{MaxRetries: -1,
+		MaxBackoff: 10 * time.Millisecond})
+	defer gpc.Close()
+	results := make(chan error, 2)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"),
+		func(err error) { results <- err }))
+	assert.NotNil(t, <-results)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(calls) == 3
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		info, err := os.Stat(path)
+		return err == nil && info.Size() == 0
+	}, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, len(okRequests), 1)
+	assert.Equal(t, len(results), 0)
+	assert.Nil(t, gpc.Flush(context.Background()))
+}
+
+func TestOutboxRedeliveryFlushAndClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	server, requests := newTestPublishServer(t, 503)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	assert.Nil(t, gpc.OpenOutbox(path))
+	gpc.StartAsync(&AsyncConfig{MaxRetries: -1,
+		MaxBackoff: 10 * time.Millisecond})
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), nil))
+	ctx, cancel := context.WithTimeout(context.Background(),
+		100*time.Millisecond)
+	defer cancel()
+	assert.True(t, errors.Is(gpc.Flush(ctx), context.DeadlineExceeded))
+	assert.True(t, len(requests) > 1)
+	assert.Nil(t, gpc.Close())
+	for len(requests) > 0 {
+		<-requests
+	}
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, len(requests), 0)
+	records, _ := readOutboxRecords(path)
+	assert.Equal(t, len(records), 1)
+}
+
+func TestOutboxPermanentFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	gpc, _ := newTestOutboxPubControl(t, path, 400)
+	results := make(chan error, 1)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"),
+		func(err error) { results <- err }))
+	assert.NotNil(t, <-results)
+	assert.Nil(t, gpc.Close())
+	records, _ := readOutboxRecords(path)
+	assert.Equal(t, len(records), 0)
+}
+
+func TestOutboxSequencedReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	seq := NewSequencer(nil)
+	gpc, requests := newTestOutboxPubControl(t, path, 503)
+	gpc.SetSequencer(seq)
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), nil))
+	assert.Nil(t, gpc.Close())
+	assert.Equal(t, (<-requests).Items[0]["id"], "1")
+
+	server, requests := newTestPublishServer(t, 200)
+	gpc = NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.SetSequencer(seq)
+	assert.Nil(t, gpc.OpenOutbox(path))
+	gpc.StartAsync(nil)
+	assert.Nil(t, gpc.Flush(context.Background()))
+	item := (<-requests).Items[0]
+	assert.Equal(t, item["id"], "1")
+	assert.Nil(t, item["prev-id"])
+	assert.Nil(t, gpc.Close())
+}
+
+func TestOutboxTornWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "outbox")
+	content := `{"op":"add","seq":0,"items":[{"channel":"a"}]}` + "\n" +
+		`{"op":"add","seq":1,"items":[{"channel":"b"}]}` + "\n" +
+		`{"op":"ack","seq":0}` + "\n" + `{"op":"add","se`
+	assert.Nil(t, os.WriteFile(path, []byte(content), 0600))
+	ob, err := openOutbox(path)
+	assert.Nil(t, err)
+	assert.Equal(t, len(ob.replay), 1)
+	assert.Equal(t, ob.replay[0].Seq, uint64(1))
+	assert.Equal(t, ob.nextSeq, uint64(2))
+	assert.Nil(t, ob.close())
+	written, _ := os.ReadFile(path)
+	assert.Equal(t, string(written),
+		`{"op":"add","seq":1,"items":[{"channel":"b"}]}`+"\n")
+}
+
+func TestOpenOutboxWhileRunning(t *testing.T) {
+	gpc := NewGripPubControl(nil)
+	gpc.StartAsync(nil)
+	defer gpc.Close()
+	assert.Equal(t, gpc.OpenOutbox(filepath.Join(t.TempDir(), "outbox")),
+		ErrAsyncRunning)
+}
@@ -0,0 +1,510 @@
+//    publisher.go
+//    ~~~~~~~~~
+//    This module implements the Publisher interface and the
+//    RecordingPublisher struct.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/fanout/go-pubcontrol"
+	"sync"
+)
+
+// The Publisher interface holds the publishing methods of GripPubControl,
+// so that code that publishes can depend on the interface and be tested
+// with a RecordingPublisher instead.
+type Publisher interface {
+
+	// Publish the specified item to the specified channel.
+	Publish(channel string, item *pubcontrol.Item) error
+
+	// The same as Publish except that the specified context is used.
+	PublishContext(ctx context.Context, channel string,
+		item *pubcontrol.Item) error
+
+	// The same as PublishContext except that the outcome of publishing to
+	// each endpoint is returned as well.
+	PublishWithResult(ctx context.Context, channel string,
+		item *pubcontrol.Item) (*PublishResult, error)
+
+	// Queue the specified item for publishing to the specified channel and
+	// call the optional callback with the result.
+	PublishAsync(channel string, item *pubcontrol.Item,
+		callback func(err error)) error
+
+	// The same as PublishAsync except that the specified context is used.
+	PublishAsyncContext(ctx context.Context, channel string,
+		item *pubcontrol.Item, callback func(err error)) error
+
+	// Wait until all of the items queued via PublishAsync have been
+	// processed or the specified context is done.
+	Flush(ctx context.Context) error
+
+	// Publish an HTTP response format message to the specified channel.
+	PublishHttpResponse(channel string, http_response interface{}, id,
+		prevId string) error
+
+	// The same as PublishHttpResponse except that the specified context is
+	// used.
+	PublishHttpResponseContext(ctx context.Context, channel string,
+		http_response interface{}, id, prevId string) error
+
+	// Publish an HTTP stream format message to the specified channel.
+	PublishHttpStream(channel string, http_stream interface{}, id,
+		prevId string) error
+
+	// The same as PublishHttpStream except that the specified context is
+	// used.
+	PublishHttpStreamContext(ctx context.Context, channel string,
+		http_stream interface{}, id, prevId string) error
+
+	// Publish an HTTP response hint to the specified channel.
+	PublishHttpResponseHint(channel string, id, prevId string) error
+
+	// The same as PublishHttpResponseHint except that the specified context
+	// is used.
+	PublishHttpResponseHintContext(ctx context.Context, channel string, id,
+		prevId string) error
+
+	// Publish an HTTP stream hint to the specified channel.
+	PublishHttpStreamHint(channel string, id, prevId string) error
+
+	// The same as PublishHttpStreamHint except that the specified context is
+	// used.
+	PublishHttpStreamHintContext(ctx context.Context, channel string, id,
+		prevId string) error
+
+	// Publish a WebSocket message format message to the specified channel.
+	PublishWebSocketMessage(channel string, ws_message interface{}, id,
+		prevId string) error
+
+	// The same as PublishWebSocketMessage except that the specified context
+	// is used.
+	PublishWebSocketMessageContext(ctx context.Context, channel string,
+		ws_message interface{}, id, prevId string) error
+
+	// Publish a single item containing the specified formats to the
+	// specified channel.
+	PublishFormats(channel string, formats []pubcontrol.Formatter, id,
+		prevId string) error
+
+	// The same as PublishFormats except that the specified context is used.
+	PublishFormatsContext(ctx context.Context, channel string,
+		formats []pubcontrol.Formatter, id, prevId string) error
+
+	// Publish the specified items together.
+	PublishBatch(items []*ChannelItem) error
+
+	// The same as PublishBatch except that the specified context is used.
+	PublishBatchContext(ctx context.Context, items []*ChannelItem) error
+
+	// Publish the specified item to each of the specified channels.
+	PublishMulti(channels []string,
+		item *pubcontrol.Item) ([]*ChannelResult, error)
+
+	// The same as PublishMulti except that the specified context is used.
+	PublishMultiContext(ctx context.Context, channels []string,
+		item *pubcontrol.Item) ([]*ChannelResult, error)
+}
+
+var _ Publisher = (*GripPubControl)(nil)
+var _ Publisher = (*RecordingPublisher)(nil)
+
+// The PublishedItem struct holds an item captured by a RecordingPublisher,
+// along with its channel, ID, previous ID and exported formats keyed by
+// format name. Export holds the item as it would have been sent to the
+// GRIP proxies, including any item meta set on the context.
+type PublishedItem struct {
+	Channel string
+	Id      string
+	PrevId  string
+	Formats map[string]interface{}
+	Export  map[string]interface{}
+}
+
+// Return the exported format with the specified name, such as
+// 'http-stream', or nil if the item does not contain it.
+func (item *PublishedItem) Format(name string) map[string]interface{} {
+	format, _ := item.Formats[name].(map[string]interface{})
+	return format
+}
+
+// Return the content of the format with the specified name, which is the
+// body of HTTP response formats and the content of other formats. Binary
+// content is decoded, and nil is returned if the item does not contain the
+// format or the format has no content.
+func (item *PublishedItem) Content(name string) []byte {
+	format := item.Format(name)
+	key := "content"
+	if name == "http-response" {
+		key = "body"
+	}
+	if content, ok := format[key].(string); ok {
+		return []byte(content)
+	}
+	if encoded, ok := format[key+"-bin"].(string); ok {
+		content, err := base64.StdEncoding.DecodeString(encoded)
+		if err == nil {
+			return content
+		}
+	}
+	return nil
+}
+
+// The TestingT interface holds the methods of testing.T used by the
+// assertion helpers of RecordingPublisher.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// The RecordingPublisher struct implements the Publisher interface by
+// recording the published items in memory instead of sending them, which
+// allows code that publishes to be unit tested without a GRIP proxy.
+type RecordingPublisher struct {
+	lock  sync.Mutex
+	items []*PublishedItem
+	err   error
+}
+
+// Create a RecordingPublisher without any recorded items.
+func NewRecordingPublisher() *RecordingPublisher {
+	return &RecordingPublisher{items: make([]*PublishedItem, 0)}
+}
+
+// Set the error that subsequent publishes fail with, in which case nothing
+// is recorded. A nil error makes publishes succeed again.
+func (rp *RecordingPublisher) SetError(err error) {
+	rp.lock.Lock()
+	rp.err = err
+	rp.lock.Unlock()
+}
+
+// Discard all of the recorded items.
+func (rp *RecordingPublisher) Reset() {
+	rp.lock.Lock()
+	rp.items = make([]*PublishedItem, 0)
+	rp.lock.Unlock()
+}
+
+// Return all of the recorded items in the order in which they were
+// published.
+func (rp *RecordingPublisher) Items() []*PublishedItem {
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+	return append([]*PublishedItem(nil), rp.items...)
+}
+
+// Return the items recorded for the specified channel in the order in
+// which they were published.
+func (rp *RecordingPublisher) ItemsFor(channel string) []*PublishedItem {
+	items := make([]*PublishedItem, 0)
+	for _, item := range rp.Items() {
+		if item.Channel == channel {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Return the item most recently recorded for the specified channel, or nil
+// if nothing was published to it.
+func (rp *RecordingPublisher) Last(channel string) *PublishedItem {
+	items := rp.ItemsFor(channel)
+	if len(items) == 0 {
+		return nil
+	}
+	return items[len(items)-1]
+}
+
+// Return the distinct channels that items were published to, in the order
+// in which they were first published to.
+func (rp *RecordingPublisher) Channels() []string {
+	channels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, item := range rp.Items() {
+		if !seen[item.Channel] {
+			seen[item.Channel] = true
+			channels = append(channels, item.Channel)
+		}
+	}
+	return channels
+}
+
+// Report a test failure if nothing was published to the specified channel,
+// and otherwise return the item most recently published to it.
+func (rp *RecordingPublisher) AssertPublished(t TestingT,
+	channel string) *PublishedItem {
+	t.Helper()
+	item := rp.Last(channel)
+	if item == nil {
+		t.Errorf("expected an item to be published to channel %q, "+
+			"published to %q", channel, rp.Channels())
+	}
+	return item
+}
+
+// Report a test failure if anything was published to the specified
+// channel.
+func (rp *RecordingPublisher) AssertNotPublished(t TestingT,
+	channel string) {
+	t.Helper()
+	if count := len(rp.ItemsFor(channel)); count > 0 {
+		t.Errorf("expected no items to be published to channel %q, "+
+			"got %d", channel, count)
+	}
+}
+
+// Report a test failure unless exactly the specified number of items was
+// published to the specified channel.
+func (rp *RecordingPublisher) AssertCount(t TestingT, channel string,
+	count int) {
+	t.Helper()
+	if actual := len(rp.ItemsFor(channel)); actual != count {
+		t.Errorf("expected %d item(s) to be published to channel %q, "+
+			"got %d", count, channel, actual)
+	}
+}
+
+// An internal method for recording the specified items. Nothing is
+// recorded if an item cannot be exported or an error has been set.
+func (rp *RecordingPublisher) record(ctx context.Context,
+	items []*ChannelItem) error {
+	exports, err := exportChannelItems(items)
+	if err != nil {
+		return err
+	}
+	rp.lock.Lock()
+	defer rp.lock.Unlock()
+	if rp.err != nil {
+		return rp.err
+	}
+	for _, export := range exports {
+		applyItemMeta(ctx, export)
+		item := &PublishedItem{Formats: make(map[string]interface{}),
+			Export: export}
+		for name, value := range export {
+			switch name {
+			case "channel":
+				item.Channel, _ = value.(string)
+			case "id":
+				item.Id, _ = value.(string)
+			case "prev-id":
+				item.PrevId, _ = value.(string)
+			case "meta":
+			default:
+				item.Formats[name] = value
+			}
+		}
+		rp.items = append(rp.items, item)
+	}
+	return nil
+}
+
+// Record the specified item as published to the specified channel.
+func (rp *RecordingPublisher) Publish(channel string,
+	item *pubcontrol.Item) error {
+	return rp.PublishContext(context.Background(), channel, item)
+}
+
+// Record the specified item as published to the specified channel. The
+// item meta of the specified context is included in the recorded export.
+func (rp *RecordingPublisher) PublishContext(ctx context.Context,
+	channel string, item *pubcontrol.Item) error {
+	return rp.record(ctx, []*ChannelItem{&ChannelItem{Channel: channel,
+		Item: item}})
+}
+
+// The same as PublishContext except that a result is returned as well.
+// Since nothing is sent, the result does not contain any endpoints.
+func (rp *RecordingPublisher) PublishWithResult(ctx context.Context,
+	channel string, item *pubcontrol.Item) (*PublishResult, error) {
+	if _, err := exportChannelItems([]*ChannelItem{&ChannelItem{
+		Channel: channel, Item: item}}); err != nil {
+		return nil, err
+	}
+	err := rp.PublishContext(ctx, channel, item)
+	return &PublishResult{Endpoints: make([]*EndpointResult, 0)}, err
+}
+
+// Record the specified item as published to the specified channel and call
+// the optional callback with the result before returning.
+func (rp *RecordingPublisher) PublishAsync(channel string,
+	item *pubcontrol.Item, callback func(err error)) error {
+	return rp.PublishAsyncContext(context.Background(), channel, item,
+		callback)
+}
+
+// The same as PublishAsync except that the item meta of the specified
+// context is included in the recorded export. As with GripPubControl, an
+// item that cannot be exported is rejected with an error without calling
+// the callback, while the error set via SetError is passed to the callback.
+func (rp *RecordingPublisher) PublishAsyncContext(ctx context.Context,
+	channel string, item *pubcontrol.Item, callback func(err error)) error {
+	if _, err := exportChannelItems([]*ChannelItem{&ChannelItem{
+		Channel: channel, Item: item}}); err != nil {
+		return err
+	}
+	err := rp.PublishContext(ctx, channel, item)
+	if callback != nil {
+		callback(err)
+	}
+	return nil
+}
+
+// Return immediately, since the items published via PublishAsync are
+// recorded before PublishAsync returns.
+func (rp *RecordingPublisher) Flush(ctx context.Context) error {
+	return nil
+}
+
+// Record an HTTP response format message as published to the specified
+// channel.
+func (rp *RecordingPublisher) PublishHttpResponse(channel string,
+	http_response interface{}, id, prevId string) error {
+	return rp.PublishHttpResponseContext(context.Background(), channel,
+		http_response, id, prevId)
+}
+
+// The same as PublishHttpResponse except that the specified context is
+// used.
+func (rp *RecordingPublisher) PublishHttpResponseContext(
+	ctx context.Context, channel string, http_response interface{}, id,
+	prevId string) error {
+	item, err := getHttpResponseItem(http_response, id, prevId)
+	if err != nil {
+		return err
+	}
+	return rp.PublishContext(ctx, channel, item)
+}
+
+// Record an HTTP stream format message as published to the specified
+// channel.
+func (rp *RecordingPublisher) PublishHttpStream(channel string,
+	http_stream interface{}, id, prevId string) error {
+	return rp.PublishHttpStreamContext(context.Background(), channel,
+		http_stream, id, prevId)
+}
+
+// The same as PublishHttpStream except that the specified context is used.
+func (rp *RecordingPublisher) PublishHttpStreamContext(ctx context.Context,
+	channel string, http_stream interface{}, id, prevId string) error {
+	item, err := getHttpStreamItem(http_stream, id, prevId)
+	if err != nil {
+		return err
+	}
+	return rp.PublishContext(ctx, channel, item)
+}
+
+// Record an HTTP response hint as published to the specified channel.
+func (rp *RecordingPublisher) PublishHttpResponseHint(channel string, id,
+	prevId string) error {
+	return rp.PublishHttpResponseHintContext(context.Background(), channel,
+		id, prevId)
+}
+
+// The same as PublishHttpResponseHint except that the specified context is
+// used.
+func (rp *RecordingPublisher) PublishHttpResponseHintContext(
+	ctx context.Context, channel string, id, prevId string) error {
+	return rp.PublishHttpResponseContext(ctx, channel,
+		&HttpResponseFormat{Hint: true}, id, prevId)
+}
+
+// Record an HTTP stream hint as published to the specified channel.
+func (rp *RecordingPublisher) PublishHttpStreamHint(channel string, id,
+	prevId string) error {
+	return rp.PublishHttpStreamHintContext(context.Background(), channel,
+		id, prevId)
+}
+
+// The same as PublishHttpStreamHint except that the specified context is
+// used.
+func (rp *RecordingPublisher) PublishHttpStreamHintContext(
+	ctx context.Context, channel string, id, prevId string) error {
+	return rp.PublishHttpStreamContext(ctx, channel,
+		&HttpStreamFormat{Hint: true}, id, prevId)
+}
+
+// Record a WebSocket message format message as published to the specified
+// channel.
+func (rp *RecordingPublisher) PublishWebSocketMessage(channel string,
+	ws_message interface{}, id, prevId string) error {
+	return rp.PublishWebSocketMessageContext(context.Background(), channel,
+		ws_message, id, prevId)
+}
+
+// The same as PublishWebSocketMessage except that the specified context is
+// used.
+func (rp *RecordingPublisher) PublishWebSocketMessageContext(
+	ctx context.Context, channel string, ws_message interface{}, id,
+	prevId string) error {
+	item, err := getWebSocketMessageItem(ws_message, id, prevId)
+	if err != nil {
+		return err
+	}
+	return rp.PublishContext(ctx, channel, item)
+}
+
+// Record a single item containing the specified formats as published to
+// the specified channel.
+func (rp *RecordingPublisher) PublishFormats(channel string,
+	formats []pubcontrol.Formatter, id, prevId string) error {
+	return rp.PublishFormatsContext(context.Background(), channel, formats,
+		id, prevId)
+}
+
+// The same as PublishFormats except that the specified context is used.
+func (rp *RecordingPublisher) PublishFormatsContext(ctx context.Context,
+	channel string, formats []pubcontrol.Formatter, id,
+	prevId string) error {
+	item, err := getFormatsItem(formats, id, prevId)
+	if err != nil {
+		return err
+	}
+	return rp.PublishContext(ctx, channel, item)
+}
+
+// Record the specified items as published.
+func (rp *RecordingPublisher) PublishBatch(items []*ChannelItem) error {
+	return rp.PublishBatchContext(context.Background(), items)
+}
+
+// The same as PublishBatch except that the specified context is used.
+func (rp *RecordingPublisher) PublishBatchContext(ctx context.Context,
+	items []*ChannelItem) error {
+	return rp.record(ctx, items)
+}
+
+// Record the specified item as published to each of the distinct specified
+// channels.
+func (rp *RecordingPublisher) PublishMulti(channels []string,
+	item *pubcontrol.Item) ([]*ChannelResult, error) {
+	return rp.PublishMultiContext(context.Background(), channels, item)
+}
+
+// The same as PublishMulti except that the specified context is used.
+func (rp *RecordingPublisher) PublishMultiContext(ctx context.Context,
+	channels []string, item *pubcontrol.Item) ([]*ChannelResult, error) {
+	if item == nil {
+		return nil, &GripPublishError{err: "item must not be nil"}
+	}
+	channels = uniqueChannels(channels)
+	items := make([]*ChannelItem, 0, len(channels))
+	for _, channel := range channels {
+		items = append(items, &ChannelItem{Channel: channel, Item: item})
+	}
+	err := rp.record(ctx, items)
+	results := make([]*ChannelResult, 0, len(channels))
+	for _, channel := range channels {
+		result := &PublishResult{Endpoints: make([]*EndpointResult, 0)}
+		results = append(results, &ChannelResult{Channel: channel,
+			Result: result, Err: err})
+	}
+	return results, err
+}
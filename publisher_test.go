@@ -0,0 +1,186 @@
+//    publisher_test.go
+//    ~~~~~~~~~
+//    This module implements the Publisher and RecordingPublisher tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// An internal struct implementing TestingT that captures failures.
+type testRecordingT struct {
+	failures []string
+}
+
+func (t *testRecordingT) Helper() {}
+
+func (t *testRecordingT) Errorf(format string, args ...interface{}) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+// An internal function standing in for application code that publishes.
+func notifyTestUser(pub Publisher, user string) error {
+	return pub.PublishHttpStream("user-"+user, "hello\n", "2", "1")
+}
+
+func TestRecordingPublisher(t *testing.T) {
+	rp := NewRecordingPublisher()
+	assert.Nil(t, notifyTestUser(rp, "a"))
+	item := rp.AssertPublished(t, "user-a")
+	assert.Equal(t, item.Channel, "user-a")
+	assert.Equal(t, item.Id, "2")
+	assert.Equal(t, item.PrevId, "1")
+	assert.Equal(t, item.Format("http-stream"),
+		map[string]interface{}{"content": "hello\n"})
+	assert.Equal(t, item.Content("http-stream"), []byte("hello\n"))
+	assert.Nil(t, item.Format("http-response"))
+	assert.Nil(t, item.Content("http-response"))
+	assert.Nil(t, rp.PublishHttpResponse("chan", []byte{0xff}, "", ""))
+	assert.Nil(t, rp.PublishWebSocketMessage("chan", "message", "", ""))
+	assert.Nil(t, rp.PublishFormats("other", []pubcontrol.Formatter{
+		&HttpStreamFormat{Close: true}}, "", ""))
+	assert.Equal(t, rp.Last("chan").Content("ws-message"), []byte("message"))
+	assert.Equal(t, rp.ItemsFor("chan")[0].Content("http-response"),
+		[]byte{0xff})
+	assert.Equal(t, rp.Channels(), []string{"user-a", "chan", "other"})
+	assert.Equal(t, len(rp.Items()), 4)
+	rp.AssertCount(t, "chan", 2)
+	rp.AssertNotPublished(t, "missing")
+	assert.Nil(t, rp.Last("missing"))
+	rp.Reset()
+	assert.Empty(t, rp.Items())
+}
+
+func TestRecordingPublisherBatchAndMulti(t *testing.T) {
+	rp := NewRecordingPublisher()
+	assert.Nil(t, rp.PublishBatch(newTestChannelItems(2)))
+	assert.Equal(t, rp.Channels(), []string{"chan0", "chan1"})
+	results, err := rp.PublishMulti([]string{"a", "b", "a"},
+		newTestItem("data"))
+	assert.Nil(t, err)
+	assert.Equal(t, len(results), 2)
+	assert.Equal(t, results[1].Channel, "b")
+	assert.Equal(t, rp.Channels(), []string{"chan0", "chan1", "a", "b"})
+	results, err = rp.PublishMulti([]string{"a"}, nil)
+	assert.Nil(t, results)
+	assert.NotNil(t, err)
+	assert.NotNil(t, rp.PublishBatch([]*ChannelItem{nil}))
+}
+
+func TestRecordingPublisherError(t *testing.T) {
+	rp := NewRecordingPublisher()
+	failure := errors.New("failure")
+	rp.SetError(failure)
+	assert.Equal(t, rp.Publish("chan", newTestItem("data")), failure)
+	results, err := rp.PublishMultiContext(context.Background(),
+		[]string{"a"}, newTestItem("data"))
+	assert.Equal(t, err, failure)
+	assert.Equal(t, results[0].Err, failure)
+	assert.Empty(t, rp.Items())
+	rp.SetError(nil)
+	assert.Nil(t, rp.Publish("chan", newTestItem("data")))
+	assert.NotNil(t, rp.PublishHttpStream("chan", 1, "", ""))
+	assert.Equal(t, len(rp.Items()), 1)
+}
+
+func TestRecordingPublisherMeta(t *testing.T) {
+	rp := NewRecordingPublisher()
+	ctx := WithItemMeta(context.Background(), "origin", "api")
+	assert.Nil(t, PublishJSONContext(ctx, rp, "chan", map[string]int{"a": 1},
+		"", ""))
+	item := rp.AssertPublished(t, "chan")
+	assert.Equal(t, item.Export["meta"], map[string]interface{}{
+		"origin": "api"})
+	assert.Nil(t, item.Formats["meta"])
+	assert.Equal(t, item.Content("http-stream"), []byte("{\"a\":1}\n"))
+}
+
+func TestRecordingPublisherAssertions(t *testing.T) {
+	rp := NewRecordingPublisher()
+	rp.Publish("chan", newTestItem("data"))
+	recorder := &testRecordingT{}
+	assert.Nil(t, rp.AssertPublished(recorder, "missing"))
+	rp.AssertNotPublished(recorder, "chan")
+	rp.AssertCount(recorder, "chan", 2)
+	assert.Equal(t, len(recorder.failures), 3)
+	assert.Contains(t, recorder.failures[0], `"missing"`)
+	assert.Contains(t, recorder.failures[1], "got 1")
+	assert.Contains(t, recorder.failures[2], "expected 2 item(s)")
+}
+
+// An internal function standing in for application code that sends hints.
+func hintTestUser(pub Publisher, user string) error {
+	if err := pub.PublishHttpResponseHint("user-"+user, "2", "1"); err != nil {
+		return err
+	}
+	return pub.PublishHttpStreamHintContext(context.Background(),
+		"user-"+user, "3", "2")
+}
+
+func TestRecordingPublisherHints(t *testing.T) {
+	rp := NewRecordingPublisher()
+	assert.Nil(t, hintTestUser(rp, "a"))
+	items := rp.ItemsFor("user-a")
+	assert.Equal(t, len(items), 2)
+	assert.Equal(t, items[0].Format("http-response"),
+		map[string]interface{}{"action": "hint"})
+	assert.Equal(t, items[0].Id, "2")
+	assert.Equal(t, items[1].Format("http-stream"),
+		map[string]interface{}{"action": "hint"})
+	assert.Equal(t, items[1].PrevId, "2")
+	assert.Nil(t, rp.PublishHttpStreamHint("b", "", ""))
+	assert.Nil(t, rp.PublishHttpResponseHintContext(context.Background(),
+		"b", "", ""))
+	rp.AssertCount(t, "b", 2)
+}
+
+// An internal function standing in for application code that publishes
+// asynchronously and inspects results.
+func notifyTestUserAsync(pub Publisher, user string,
+	callback func(err error)) (*PublishResult, error) {
+	if err := pub.PublishAsync("user-"+user, newTestItem("a"),
+		callback); err != nil {
+		return nil, err
+	}
+	if err := pub.Flush(context.Background()); err != nil {
+		return nil, err
+	}
+	return pub.PublishWithResult(context.Background(), "user-"+user,
+		newTestItem("b"))
+}
+
+func TestRecordingPublisherAsyncAndResult(t *testing.T) {
+	rp := NewRecordingPublisher()
+	var callbackErrs []error
+	callback := func(err error) { callbackErrs = append(callbackErrs, err) }
+	result, err := notifyTestUserAsync(rp, "a", callback)
+	assert.Nil(t, err)
+	assert.Empty(t, result.Endpoints)
+	assert.Equal(t, callbackErrs, []error{nil})
+	rp.AssertCount(t, "user-a", 2)
+	failure := errors.New("failure")
+	rp.SetError(failure)
+	result, err = notifyTestUserAsync(rp, "b", callback)
+	assert.Equal(t, err, failure)
+	assert.NotNil(t, result)
+	assert.Equal(t, callbackErrs, []error{nil, failure})
+	rp.AssertNotPublished(t, "user-b")
+	rp.SetError(nil)
+	assert.NotNil(t, rp.PublishAsyncContext(context.Background(), "c", nil,
+		callback))
+	result, err = rp.PublishWithResult(context.Background(), "c", nil)
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+	assert.Equal(t, len(callbackErrs), 2)
+	assert.Nil(t, rp.PublishAsync("c", newTestItem("data"), nil))
+	rp.AssertCount(t, "c", 1)
+}
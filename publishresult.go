@@ -0,0 +1,168 @@
+//    publishresult.go
+//    ~~~~~~~~~
+//    This module implements the publish result structs and errors.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The error category matched via errors.Is by EndpointErrors for which the
+// endpoint responded with a 4xx status code.
+var ErrEndpointRejected = errors.New("endpoint rejected the publish")
+
+// The error category matched via errors.Is by EndpointErrors for which the
+// endpoint responded with any other failure status code.
+var ErrEndpointFailed = errors.New("endpoint failed to publish")
+
+// The error category matched via errors.Is by EndpointErrors for which no
+// response was received from the endpoint, such as network errors. Errors
+// caused by an open circuit breaker only match ErrCircuitOpen.
+var ErrEndpointUnreachable = errors.New("endpoint is unreachable")
+
+// The error category matched via errors.Is by EndpointErrors for which the
+// endpoint did not respond in time, such as when the timeout of the HTTP
+// client was exceeded. Failures caused by the caller's context being
+// canceled or exceeding its deadline are not timeouts of the endpoint.
+var ErrEndpointTimeout = errors.New("endpoint timed out")
+
+// The EndpointResult struct holds the outcome of publishing to a single
+// endpoint. The name is only set for named endpoints, and Bytes is the size
+// of the request body, which is zero for wrapped PubControlClient
+// instances. The status code and body are only set if a response was
+// received, and Err is nil if the publish succeeded. Skipped is set if the
+// endpoint was not published to because the delivery policy was already
+// satisfied.
+type EndpointResult struct {
+	Uri        string
+	Name       string
+	StatusCode int
+	Body       []byte
+	Bytes      int
+	Latency    time.Duration
+	Err        error
+	Skipped    bool
+}
+
+// The PublishResult struct holds the outcome of publishing to each of the
+// configured endpoints, in the order in which the endpoints were added.
+type PublishResult struct {
+	Endpoints []*EndpointResult
+}
+
+// Return the number of endpoints that the publish succeeded for.
+func (result *PublishResult) Succeeded() int {
+	count := 0
+	for _, endpoint := range result.Endpoints {
+		if endpoint.Err == nil && !endpoint.Skipped {
+			count++
+		}
+	}
+	return count
+}
+
+// Return the results of the endpoints that the publish failed for.
+func (result *PublishResult) Failed() []*EndpointResult {
+	failed := make([]*EndpointResult, 0)
+	for _, endpoint := range result.Endpoints {
+		if endpoint.Err != nil {
+			failed = append(failed, endpoint)
+		}
+	}
+	return failed
+}
+
+// An error object representing a failure to publish to a single endpoint.
+// It matches ErrEndpointRejected, ErrEndpointFailed, ErrEndpointUnreachable
+// or ErrEndpointTimeout via errors.Is depending on the failure, and unwraps
+// to the underlying error if there is one.
+type EndpointError struct {
+	Uri        string
+	StatusCode int
+	Body       []byte
+	Err        error
+	// Set if the publish failed because the caller's context was canceled
+	// or exceeded its deadline rather than because of the endpoint.
+	callerDone bool
+}
+
+// The function used to retrieve the message associated with an
+// EndpointError.
+func (e *EndpointError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return "Failure status code: " + strconv.Itoa(e.StatusCode) +
+		" with message: " + string(e.Body)
+}
+
+// Return the underlying error.
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// Report whether the error belongs to the specified error category.
+func (e *EndpointError) Is(target error) bool {
+	switch target {
+	case ErrEndpointRejected:
+		return e.StatusCode >= 400 && e.StatusCode < 500
+	case ErrEndpointFailed:
+		return e.StatusCode != 0 && (e.StatusCode < 400 ||
+			e.StatusCode >= 500)
+	case ErrEndpointUnreachable:
+		var publishErr *GripPublishError
+		return e.StatusCode == 0 && e.Err != nil && !e.callerDone &&
+			!errors.Is(e.Err, context.Canceled) &&
+			!errors.Is(e.Err, ErrCircuitOpen) && !isTimeoutError(e.Err) &&
+			!errors.As(e.Err, &publishErr)
+	case ErrEndpointTimeout:
+		return e.StatusCode == 0 && !e.callerDone && isTimeoutError(e.Err)
+	}
+	return false
+}
+
+// An internal method for determining whether the specified error is a
+// timeout, such as the timeout of the HTTP client being exceeded.
+func isTimeoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// An internal method for aggregating the failures in the specified
+// endpoint results into one GripPublishError that carries the results.
+// Nil is returned if the results satisfy the specified delivery policy.
+func aggregatePublishErrors(policy DeliveryPolicy, results []*EndpointResult,
+	target string) error {
+	if isPolicySatisfied(policy, results) {
+		return nil
+	}
+	messages := make([]string, 0)
+	errs := make([]error, 0)
+	for _, result := range results {
+		if result.Err != nil {
+			messages = append(messages, fmt.Sprintf("%s: %s", result.Uri,
+				strings.TrimSpace(result.Err.Error())))
+			errs = append(errs, result.Err)
+		}
+	}
+	return &GripPublishError{err: fmt.Sprintf("%d/%d client(s) failed to "+
+		"publish to %s Errors: [%s]", len(errs), len(results), target,
+		strings.Join(messages, "],[")), errs: errs,
+		result: &PublishResult{Endpoints: results}}
+}
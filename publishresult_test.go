@@ -0,0 +1,117 @@
+//    publishresult_test.go
+//    ~~~~~~~~~
+//    This module implements the publish result tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestPublishWithResult(t *testing.T) {
+	okServer, _ := newTestPublishServer(t, 200)
+	badServer, _ := newTestPublishServer(t, 404)
+	failServer, _ := newTestPublishServer(t, 503)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": okServer.URL},
+		map[string]interface{}{"control_uri": badServer.URL},
+		map[string]interface{}{"control_uri": failServer.URL},
+		map[string]interface{}{"control_uri": "http://127.0.0.1:1"}})
+	result, err := gpc.PublishWithResult(context.Background(), "chan",
+		newTestItem("data"))
+	assert.NotNil(t, err)
+	assert.Equal(t, len(result.Endpoints), 4)
+	assert.Equal(t, result.Succeeded(), 1)
+	assert.Equal(t, len(result.Failed()), 3)
+	ok := result.Endpoints[0]
+	assert.Equal(t, ok.Uri, okServer.URL)
+	assert.Equal(t, ok.StatusCode, 200)
+	assert.Equal(t, ok.Body, []byte("result"))
+	assert.True(t, ok.Latency > 0)
+	assert.Nil(t, ok.Err)
+	assert.Equal(t, result.Endpoints[1].StatusCode, 404)
+	assert.True(t, errors.Is(result.Endpoints[1].Err, ErrEndpointRejected))
+	assert.True(t, errors.Is(result.Endpoints[2].Err, ErrEndpointFailed))
+	assert.True(t, errors.Is(result.Endpoints[3].Err,
+		ErrEndpointUnreachable))
+	assert.True(t, errors.Is(err, ErrEndpointRejected))
+	assert.True(t, errors.Is(err, ErrEndpointFailed))
+	assert.True(t, errors.Is(err, ErrEndpointUnreachable))
+	var publishErr *GripPublishError
+	assert.True(t, errors.As(err, &publishErr))
+	assert.Equal(t, publishErr.Result().Endpoints, result.Endpoints)
+	var endpointErr *EndpointError
+	assert.True(t, errors.As(err, &endpointErr))
+	assert.Equal(t, endpointErr.Uri, badServer.URL)
+	assert.Equal(t, endpointErr.Body, []byte("result"))
+	assert.Contains(t, err.Error(), "3/4 client(s) failed to publish to "+
+		"channel: chan")
+	err = gpc.PublishHttpStream("chan", "data", "", "")
+	assert.True(t, errors.Is(err, ErrEndpointRejected))
+}
+
+func TestPublishWithResultContext(t *testing.T) {
+	server, _ := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl([]map[string]interface{}{
+		map[string]interface{}{"control_uri": server.URL}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := gpc.PublishWithResult(ctx, "chan", newTestItem("data"))
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	assert.Equal(t, len(result.Failed()), 1)
+	result, err = gpc.PublishWithResult(context.Background(), "chan", nil)
+	assert.Nil(t, result)
+	assert.NotNil(t, err)
+}
+
+func TestEndpointError(t *testing.T) {
+	err := &EndpointError{StatusCode: 500, Body: []byte("body")}
+	assert.Equal(t, err.Error(), "Failure status code: 500 with message: body")
+	assert.Nil(t, err.Unwrap())
+	assert.False(t, errors.Is(err, ErrEndpointRejected))
+	assert.True(t, errors.Is(err, ErrEndpointFailed))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	netErr := &net.OpError{Op: "dial", Err: errors.New("refused")}
+	err = &EndpointError{Err: netErr}
+	assert.Equal(t, err.Error(), netErr.Error())
+	assert.True(t, errors.Is(err, ErrEndpointUnreachable))
+	var opErr *net.OpError
+	assert.True(t, errors.As(err, &opErr))
+	err = &EndpointError{Err: ErrCircuitOpen}
+	assert.True(t, errors.Is(err, ErrCircuitOpen))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	assert.Nil(t, aggregatePublishErrors(DeliverAll, []*EndpointResult{
+		&EndpointResult{}}, "channel: chan"))
+}
+
+func TestEndpointErrorTimeout(t *testing.T) {
+	gpcc := newTestHangingClient(t, 50*time.Millisecond)
+	err := gpcc.Publish("chan", newTestItem("data"))
+	assert.True(t, errors.Is(err, ErrEndpointTimeout))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	assert.False(t, errors.Is(err, ErrEndpointFailed))
+	gpcc.httpClient.Timeout = time.Minute
+	ctx, cancel := context.WithTimeout(context.Background(),
+		50*time.Millisecond)
+	defer cancel()
+	err = gpcc.PublishContext(ctx, "chan", newTestItem("data"))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, ErrEndpointTimeout))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	err = &EndpointError{Err: &net.OpError{Op: "read",
+		Err: os.ErrDeadlineExceeded}}
+	assert.True(t, errors.Is(err, ErrEndpointTimeout))
+	assert.False(t, errors.Is(err, ErrEndpointUnreachable))
+	assert.False(t, errors.Is(&EndpointError{StatusCode: 504},
+		ErrEndpointTimeout))
+}
@@ -0,0 +1,119 @@
+//    secretresolver.go
+//    ~~~~~~~~~
+//    This module implements the SecretResolver interface and the built-in
+//    secret resolvers.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import "encoding/base64"
+import "os"
+import "strings"
+import "sync"
+
+// The SecretResolver interface is used to look up secret values that are
+// referenced from GRIP URIs rather than included in them. A reference such
+// as 'env:PUSHPIN_KEY' is passed to the resolver registered for the 'env'
+// scheme with the scheme prefix removed.
+type SecretResolver interface {
+
+	// Return the secret value for the specified reference or an error if
+	// the secret could not be found.
+	ResolveSecret(ref string) ([]byte, error)
+}
+
+// The SecretResolverFunc type allows an ordinary function to be used as a
+// SecretResolver.
+type SecretResolverFunc func(ref string) ([]byte, error)
+
+// Call the underlying function with the specified reference.
+func (f SecretResolverFunc) ResolveSecret(ref string) ([]byte, error) {
+	return f(ref)
+}
+
+var secretResolvers = map[string]SecretResolver{
+	"env":  SecretResolverFunc(resolveEnvSecret),
+	"file": SecretResolverFunc(resolveFileSecret)}
+var secretResolversLock sync.RWMutex
+
+// Register a SecretResolver for the specified scheme so that GRIP URI key
+// values of the form '<scheme>:<ref>' are resolved through it when parsed.
+// Registering a nil resolver removes the scheme. The built-in 'env' and
+// 'file' schemes can be replaced this way as well.
+func RegisterSecretResolver(scheme string, resolver SecretResolver) {
+	secretResolversLock.Lock()
+	defer secretResolversLock.Unlock()
+	if resolver == nil {
+		delete(secretResolvers, scheme)
+	} else {
+		secretResolvers[scheme] = resolver
+	}
+}
+
+// Resolve the specified secret value. Values prefixed with the scheme of a
+// registered SecretResolver are passed to that resolver, values prefixed
+// with 'base64:' are decoded, and all other values are returned as-is. A
+// resolved secret may itself be prefixed with 'base64:' in which case it
+// is decoded as well.
+func ResolveSecret(value string) ([]byte, error) {
+	at := strings.Index(value, ":")
+	if at == -1 {
+		return []byte(value), nil
+	}
+	secretResolversLock.RLock()
+	resolver, ok := secretResolvers[value[:at]]
+	secretResolversLock.RUnlock()
+	if !ok {
+		return decodeSecret(value)
+	}
+	secret, err := resolver.ResolveSecret(value[at+1:])
+	if err != nil {
+		return nil, err
+	}
+	return decodeSecret(string(secret))
+}
+
+// An internal method for decoding a secret value that is optionally
+// prefixed with 'base64:'.
+func decodeSecret(value string) ([]byte, error) {
+	if strings.HasPrefix(value, "base64:") {
+		return base64.StdEncoding.DecodeString(value[7:])
+	}
+	return []byte(value), nil
+}
+
+// An internal method used to resolve secrets stored in environment
+// variables.
+func resolveEnvSecret(ref string) ([]byte, error) {
+	value, ok := os.LookupEnv(ref)
+	if !ok {
+		return nil, &GripSecretError{err: "environment variable " + ref +
+			" is not set"}
+	}
+	return []byte(value), nil
+}
+
+// An internal method used to resolve secrets stored in files. Trailing
+// line breaks are removed since secret files are commonly written with
+// one.
+func resolveFileSecret(ref string) ([]byte, error) {
+	data, err := os.ReadFile(ref)
+	if err != nil {
+		return nil, &GripSecretError{err: "unable to read secret file: " +
+			err.Error()}
+	}
+	return []byte(strings.TrimRight(string(data), "\r\n")), nil
+}
+
+// An error object used to represent a failure to resolve a secret.
+type GripSecretError struct {
+	err string
+}
+
+// The function used to retrieve the message associated with a
+// GripSecretError.
+func (e GripSecretError) Error() string {
+	return e.err
+}
@@ -0,0 +1,75 @@
+//    secretresolver_test.go
+//    ~~~~~~~~~
+//    This module implements the SecretResolver tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	secret, err := ResolveSecret("key")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	secret, err = ResolveSecret("base64:a2V5")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	secret, err = ResolveSecret("unknown:key")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("unknown:key"))
+	_, err = ResolveSecret("base64:!!")
+	assert.NotNil(t, err)
+}
+
+func TestResolveEnvSecret(t *testing.T) {
+	t.Setenv("GRIPCONTROL_TEST_KEY", "key")
+	secret, err := ResolveSecret("env:GRIPCONTROL_TEST_KEY")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	t.Setenv("GRIPCONTROL_TEST_KEY", "base64:a2V5")
+	secret, err = ResolveSecret("env:GRIPCONTROL_TEST_KEY")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	secret, err = ResolveSecret("env:GRIPCONTROL_TEST_MISSING")
+	assert.Nil(t, secret)
+	assert.NotNil(t, err)
+}
+
+func TestResolveFileSecret(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "grip-key")
+	assert.Nil(t, os.WriteFile(path, []byte("key\n"), 0600))
+	secret, err := ResolveSecret("file:" + path)
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	secret, err = ResolveSecret("file:" + path + ".missing")
+	assert.Nil(t, secret)
+	assert.NotNil(t, err)
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+	RegisterSecretResolver("vault", SecretResolverFunc(
+		func(ref string) ([]byte, error) {
+			if ref == "grip" {
+				return []byte("key"), nil
+			}
+			return nil, errors.New("not found")
+		}))
+	defer RegisterSecretResolver("vault", nil)
+	secret, err := ResolveSecret("vault:grip")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("key"))
+	_, err = ResolveSecret("vault:other")
+	assert.NotNil(t, err)
+	RegisterSecretResolver("vault", nil)
+	secret, err = ResolveSecret("vault:grip")
+	assert.Nil(t, err)
+	assert.Equal(t, secret, []byte("vault:grip"))
+}
@@ -0,0 +1,287 @@
+//    sequencer.go
+//    ~~~~~~~~~
+//    This module implements the Sequencer struct and the SequenceStore
+//    interface.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// The SequenceStore interface holds the sequence number of each channel.
+// Implementations backed by a shared database allow multiple publisher
+// instances to share the sequence of a channel.
+type SequenceStore interface {
+
+	// Atomically increment the sequence number of the specified channel
+	// and return the new value. The first value of a channel is 1.
+	Increment(ctx context.Context, channel string) (int64, error)
+}
+
+// An internal struct implementing an in-memory SequenceStore.
+type memorySequenceStore struct {
+	lock      sync.Mutex
+	sequences map[string]int64
+}
+
+// Create a SequenceStore that keeps the sequence numbers in memory and is
+// therefore only suitable for a single publisher instance.
+func NewMemorySequenceStore() SequenceStore {
+	return &memorySequenceStore{sequences: make(map[string]int64)}
+}
+
+// Increment the sequence number of the specified channel.
+func (store *memorySequenceStore) Increment(ctx context.Context,
+	channel string) (int64, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.sequences[channel]++
+	return store.sequences[channel], nil
+}
+
+// The Sequencer struct assigns monotonically increasing IDs to the items
+// published to each channel and sets the previous ID of each item to the
+// ID of the item published before it, as required by GRIP proxies for
+// reliable delivery. Publishes to a channel are serialized from the moment
+// their IDs are assigned until they have been delivered, so that items
+// reach the GRIP proxies in the order of their IDs. IDs that none of the
+// endpoints accepted are reused for the next items of the channel so that
+// the previous ID of those items is one that the proxies have seen. Both
+// guarantees only hold within a single Sequencer, even if its store is
+// shared with other publisher instances.
+type Sequencer struct {
+	store    SequenceStore
+	lock     sync.Mutex
+	channels map[string]*sequencerChannel
+}
+
+// An internal struct holding the state of a single channel of a Sequencer.
+// The lock is held while items of the channel are being published, unused
+// holds the values that can be reused in ascending order, and refs counts
+// the publishes waiting for or holding the lock.
+type sequencerChannel struct {
+	lock   chan struct{}
+	unused []int64
+	refs   int
+}
+
+// Create a Sequencer that uses the specified store, or an in-memory store
+// if store is nil.
+func NewSequencer(store SequenceStore) *Sequencer {
+	if store == nil {
+		store = NewMemorySequenceStore()
+	}
+	return &Sequencer{store: store,
+		channels: make(map[string]*sequencerChannel)}
+}
+
+// Return the next ID of the specified channel along with the previous ID,
+// which is empty for the first item of a channel. The ID is taken from the
+// IDs that are to be reused if there are any.
+func (seq *Sequencer) Next(ctx context.Context,
+	channel string) (string, string, error) {
+	value, err := seq.nextValue(ctx, channel)
+	if err != nil {
+		return "", "", err
+	}
+	id, prevId := formatSequenceValue(value)
+	return id, prevId, nil
+}
+
+// An internal method for returning the next sequence value of the
+// specified channel, reusing the lowest unused value if there is one.
+func (seq *Sequencer) nextValue(ctx context.Context,
+	channel string) (int64, error) {
+	seq.lock.Lock()
+	if state := seq.channels[channel]; state != nil &&
+		len(state.unused) > 0 {
+		value := state.unused[0]
+		state.unused = state.unused[1:]
+		if state.refs == 0 && len(state.unused) == 0 {
+			delete(seq.channels, channel)
+		}
+		seq.lock.Unlock()
+		return value, nil
+	}
+	seq.lock.Unlock()
+	return seq.store.Increment(ctx, channel)
+}
+
+// An internal function for returning the ID and previous ID of the
+// specified sequence value.
+func formatSequenceValue(value int64) (string, string) {
+	prevId := ""
+	if value > 1 {
+		prevId = strconv.FormatInt(value-1, 10)
+	}
+	return strconv.FormatInt(value, 10), prevId
+}
+
+// An internal struct representing the channels locked by a single publish
+// and the sequence values assigned to its items.
+type sequenceReservation struct {
+	seq      *Sequencer
+	channels []string
+	values   map[string][]int64
+}
+
+// An internal method for locking the specified channels for a publish,
+// waiting for the publishes that hold them to finish. The channels are
+// locked in sorted order so that publishes to several channels cannot
+// deadlock. The context error is returned if it is done first.
+func (seq *Sequencer) reserve(ctx context.Context,
+	channels []string) (*sequenceReservation, error) {
+	sorted := append([]string(nil), channels...)
+	sort.Strings(sorted)
+	r := &sequenceReservation{seq: seq, channels: make([]string, 0,
+		len(sorted)), values: make(map[string][]int64)}
+	for _, channel := range sorted {
+		seq.lock.Lock()
+		state := seq.channels[channel]
+		if state == nil {
+			state = &sequencerChannel{lock: make(chan struct{}, 1)}
+			seq.channels[channel] = state
+		}
+		state.refs++
+		seq.lock.Unlock()
+		select {
+		case state.lock <- struct{}{}:
+			r.channels = append(r.channels, channel)
+		case <-ctx.Done():
+			seq.unref(channel, state)
+			r.release(nil)
+			return nil, ctx.Err()
+		}
+	}
+	return r, nil
+}
+
+// An internal method for dropping a reference to the state of the specified
+// channel, which is discarded once it is unused. The lock must not be held
+// by the caller.
+func (seq *Sequencer) unref(channel string, state *sequencerChannel) {
+	seq.lock.Lock()
+	defer seq.lock.Unlock()
+	state.refs--
+	if state.refs == 0 && len(state.unused) == 0 {
+		delete(seq.channels, channel)
+	}
+}
+
+// An internal method for returning the next ID and previous ID of the
+// specified locked channel.
+func (r *sequenceReservation) next(ctx context.Context,
+	channel string) (string, string, error) {
+	value, err := r.seq.nextValue(ctx, channel)
+	if err != nil {
+		return "", "", err
+	}
+	r.values[channel] = append(r.values[channel], value)
+	id, prevId := formatSequenceValue(value)
+	return id, prevId, nil
+}
+
+// An internal method for keeping the assigned values and unlocking the
+// channels of the reservation.
+func (r *sequenceReservation) keep() {
+	if r != nil {
+		r.unlock(nil)
+	}
+}
+
+// An internal method for unlocking the channels of the reservation once
+// its exports have been published. The values assigned to the channels
+// that are not in the specified accepted channels are returned to the
+// sequencer to be reused.
+func (r *sequenceReservation) release(accepted map[string]bool) {
+	if r == nil {
+		return
+	}
+	r.unlock(func(channel string) bool { return !accepted[channel] })
+}
+
+// An internal method for unlocking the channels of the reservation and
+// returning the values of the channels for which the specified function
+// returns true, if one is specified.
+func (r *sequenceReservation) unlock(reuse func(channel string) bool) {
+	seq := r.seq
+	for _, channel := range r.channels {
+		seq.lock.Lock()
+		state := seq.channels[channel]
+		if values := r.values[channel]; reuse != nil && reuse(channel) &&
+			len(values) > 0 {
+			unused := append(state.unused, values...)
+			sort.Slice(unused, func(i, j int) bool {
+				return unused[i] < unused[j]
+			})
+			state.unused = unused
+		}
+		seq.lock.Unlock()
+		<-state.lock
+		seq.unref(channel, state)
+	}
+	r.channels = nil
+}
+
+// Set the sequencer used to assign the ID and previous ID of published
+// items that have no ID of their own. Setting a nil sequencer disables
+// automatic sequencing.
+func (gpc *GripPubControl) SetSequencer(seq *Sequencer) {
+	gpc.settingsRWLock.Lock()
+	defer gpc.settingsRWLock.Unlock()
+	gpc.sequencer = seq
+}
+
+// An internal method for assigning the ID and previous ID of the specified
+// exports that have no ID via the sequencer, if one is set. A previous ID
+// that is already set is left as is. The channels of the sequenced exports
+// stay locked until the returned reservation is released, which is nil if
+// there is nothing to sequence.
+func (gpc *GripPubControl) sequenceExports(ctx context.Context,
+	exports []map[string]interface{}) (*sequenceReservation, error) {
+	gpc.settingsRWLock.RLock()
+	seq := gpc.sequencer
+	gpc.settingsRWLock.RUnlock()
+	if seq == nil {
+		return nil, nil
+	}
+	channels := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, export := range exports {
+		channel, _ := export["channel"].(string)
+		if _, ok := export["id"]; !ok && !seen[channel] {
+			seen[channel] = true
+			channels = append(channels, channel)
+		}
+	}
+	if len(channels) == 0 {
+		return nil, nil
+	}
+	reservation, err := seq.reserve(ctx, channels)
+	if err != nil {
+		return nil, err
+	}
+	for _, export := range exports {
+		if _, ok := export["id"]; ok {
+			continue
+		}
+		id, prevId, err := reservation.next(ctx,
+			export["channel"].(string))
+		if err != nil {
+			reservation.release(nil)
+			return nil, err
+		}
+		export["id"] = id
+		if _, ok := export["prev-id"]; !ok && prevId != "" {
+			export["prev-id"] = prevId
+		}
+	}
+	return reservation, nil
+}
@@ -0,0 +1,180 @@
+//    sequencer_test.go
+//    ~~~~~~~~~
+//    This module implements the Sequencer tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"errors"
+	"github.com/fanout/go-pubcontrol"
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// An internal struct implementing a SequenceStore that always fails.
+type failingSequenceStore struct{}
+
+func (store failingSequenceStore) Increment(ctx context.Context,
+	channel string) (int64, error) {
+	return 0, errors.New("store failed")
+}
+
+func TestSequencerNext(t *testing.T) {
+	seq := NewSequencer(nil)
+	id, prevId, err := seq.Next(context.Background(), "a")
+	assert.Nil(t, err)
+	assert.Equal(t, id, "1")
+	assert.Equal(t, prevId, "")
+	id, prevId, _ = seq.Next(context.Background(), "a")
+	assert.Equal(t, id, "2")
+	assert.Equal(t, prevId, "1")
+	id, prevId, _ = seq.Next(context.Background(), "b")
+	assert.Equal(t, id, "1")
+	assert.Equal(t, prevId, "")
+}
+
+func TestSequencerSharedStore(t *testing.T) {
+	store := NewMemorySequenceStore()
+	first := NewSequencer(store)
+	second := NewSequencer(store)
+	first.Next(context.Background(), "chan")
+	id, prevId, _ := second.Next(context.Background(), "chan")
+	assert.Equal(t, id, "2")
+	assert.Equal(t, prevId, "1")
+}
+
+func TestSetSequencer(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.SetSequencer(NewSequencer(nil))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "1", "", ""))
+	assert.Equal(t, (<-requests).Items[0]["id"], "1")
+	assert.Nil(t, gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "chan", Item: newTestItem("2")},
+		&ChannelItem{Channel: "other", Item: newTestItem("3")},
+		&ChannelItem{Channel: "chan", Item: pubcontrol.NewItem(
+			[]pubcontrol.Formatter{&HttpStreamFormat{
+				Content: []byte("4")}}, "custom", "")},
+		&ChannelItem{Channel: "chan", Item: newTestItem("5")}}))
+	items := (<-requests).Items
+	assert.Equal(t, items[0]["id"], "2")
+	assert.Equal(t, items[0]["prev-id"], "1")
+	assert.Equal(t, items[1]["id"], "1")
+	assert.Nil(t, items[1]["prev-id"])
+	assert.Equal(t, items[2]["id"], "custom")
+	assert.Nil(t, items[2]["prev-id"])
+	assert.Equal(t, items[3]["id"], "3")
+	assert.Equal(t, items[3]["prev-id"], "2")
+	gpc.SetSequencer(nil)
+	assert.Nil(t, gpc.PublishHttpStream("chan", "6", "", ""))
+	assert.Nil(t, (<-requests).Items[0]["id"])
+}
+
+func TestSequencerAsync(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.SetSequencer(NewSequencer(nil))
+	gpc.StartAsync(nil)
+	defer gpc.Close()
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("1"), nil))
+	assert.Nil(t, gpc.PublishAsync("chan", newTestItem("2"), nil))
+	assert.Nil(t, gpc.Flush(context.Background()))
+	assert.Equal(t, (<-requests).Items[0]["id"], "1")
+	item := (<-requests).Items[0]
+	assert.Equal(t, item["id"], "2")
+	assert.Equal(t, item["prev-id"], "1")
+}
+
+func TestSequencerStoreError(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.SetSequencer(NewSequencer(failingSequenceStore{}))
+	err := gpc.PublishHttpStream("chan", "data", "", "")
+	assert.Equal(t, err.Error(), "store failed")
+	assert.Equal(t, len(requests), 0)
+}
+
+func TestSequencerWrappedClient(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddClient(pubcontrol.NewPubControlClient(server.URL))
+	gpc.SetSequencer(NewSequencer(nil))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "1", "", ""))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "2", "", ""))
+	assert.Equal(t, (<-requests).Items[0]["id"], "1")
+	item := (<-requests).Items[0]
+	assert.Equal(t, item["id"], "2")
+	assert.Equal(t, item["prev-id"], "1")
+	assert.Equal(t, item["http-stream"],
+		map[string]interface{}{"content": "2"})
+}
+
+func TestSequencerReusesUndeliveredIds(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient("http://127.0.0.1:1"))
+	seq := NewSequencer(nil)
+	gpc.SetSequencer(seq)
+	assert.NotNil(t, gpc.PublishBatch([]*ChannelItem{
+		&ChannelItem{Channel: "chan", Item: newTestItem("1")},
+		&ChannelItem{Channel: "chan", Item: newTestItem("2")}}))
+	gpc.RemoveAllClients()
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	assert.Nil(t, gpc.PublishHttpStream("chan", "3", "", ""))
+	item := (<-requests).Items[0]
+	assert.Equal(t, item["id"], "1")
+	assert.Nil(t, item["prev-id"])
+	id, prevId, err := seq.Next(context.Background(), "chan")
+	assert.Nil(t, err)
+	assert.Equal(t, id, "2")
+	assert.Equal(t, prevId, "1")
+	id, _, _ = seq.Next(context.Background(), "chan")
+	assert.Equal(t, id, "3")
+	assert.Empty(t, seq.channels)
+}
+
+func TestSequencerSerializesChannel(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	gpc.SetSequencer(NewSequencer(nil))
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, gpc.PublishHttpStream("chan", "data", "", ""))
+		}()
+	}
+	wg.Wait()
+	for i := 1; i <= 20; i++ {
+		assert.Equal(t, (<-requests).Items[0]["id"], strconv.Itoa(i))
+	}
+}
+
+func TestSequencerReserveContext(t *testing.T) {
+	seq := NewSequencer(nil)
+	reservation, err := seq.reserve(context.Background(),
+		[]string{"b", "a"})
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(),
+		10*time.Millisecond)
+	defer cancel()
+	_, err = seq.reserve(ctx, []string{"c", "b"})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	reservation.keep()
+	reservation, err = seq.reserve(context.Background(), []string{"b"})
+	assert.Nil(t, err)
+	reservation.keep()
+	assert.Empty(t, seq.channels)
+}
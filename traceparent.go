@@ -0,0 +1,54 @@
+//    traceparent.go
+//    ~~~~~~~~~
+//    This module implements the W3C Trace Context propagation features.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// The item meta key under which the traceparent is published.
+const TraceparentMetaKey = "traceparent"
+
+// The regular expression matching a W3C Trace Context traceparent value.
+var traceparentRegexp = regexp.MustCompile(
+	"^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$")
+
+// Return whether the specified value is a valid W3C Trace Context
+// traceparent. The version must not be 'ff' and neither the trace ID nor
+// the parent ID may be all zeros.
+func IsValidTraceparent(traceparent string) bool {
+	if !traceparentRegexp.MatchString(traceparent) {
+		return false
+	}
+	parts := strings.Split(traceparent, "-")
+	return parts[0] != "ff" && strings.Trim(parts[1], "0") != "" &&
+		strings.Trim(parts[2], "0") != ""
+}
+
+// Return a copy of the specified context that propagates the specified
+// W3C traceparent into the 'meta' of the items published with it, so that
+// subscribers can continue the trace. The context is returned as is if the
+// traceparent is not valid.
+func WithTraceparent(ctx context.Context,
+	traceparent string) context.Context {
+	traceparent = strings.TrimSpace(traceparent)
+	if !IsValidTraceparent(traceparent) {
+		return ctx
+	}
+	return WithItemMeta(ctx, TraceparentMetaKey, traceparent)
+}
+
+// Return a copy of the context of the specified request that propagates
+// the traceparent header of the request, if there is a valid one, into
+// the items published with it.
+func WithRequestTraceparent(r *http.Request) context.Context {
+	return WithTraceparent(r.Context(), r.Header.Get("traceparent"))
+}
@@ -0,0 +1,49 @@
+//    traceparent_test.go
+//    ~~~~~~~~~
+//    This module implements the W3C Trace Context propagation tests.
+//    :authors: agent.
+//    :copyright: (c) 2026 by Fanout, Inc.
+//    :license: MIT, see LICENSE for more details.
+
+package gripcontrol
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+const testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-" +
+	"00f067aa0ba902b7-01"
+
+func TestIsValidTraceparent(t *testing.T) {
+	assert.True(t, IsValidTraceparent(testTraceparent))
+	assert.False(t, IsValidTraceparent(""))
+	assert.False(t, IsValidTraceparent("00-4BF92F3577B34DA6A3CE929D0E0E4736-"+
+		"00f067aa0ba902b7-01"))
+	assert.False(t, IsValidTraceparent("ff-4bf92f3577b34da6a3ce929d0e0e4736-"+
+		"00f067aa0ba902b7-01"))
+	assert.False(t, IsValidTraceparent("00-00000000000000000000000000000000-"+
+		"00f067aa0ba902b7-01"))
+	assert.False(t, IsValidTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-"+
+		"0000000000000000-01"))
+}
+
+func TestWithTraceparent(t *testing.T) {
+	server, requests := newTestPublishServer(t, 200)
+	gpc := NewGripPubControl(nil)
+	gpc.AddGripClient(NewGripPubControlClient(server.URL))
+	ctx := context.Background()
+	assert.Equal(t, WithTraceparent(ctx, "invalid"), ctx)
+	assert.Nil(t, gpc.PublishHttpStreamContext(WithTraceparent(ctx,
+		testTraceparent), "chan", "data", "", ""))
+	assert.Equal(t, (<-requests).Items[0]["meta"], map[string]interface{}{
+		"traceparent": testTraceparent})
+	r, _ := http.NewRequest("GET", "http://localhost/", nil)
+	r.Header.Set("traceparent", " "+testTraceparent)
+	assert.Nil(t, gpc.PublishHttpStreamContext(WithRequestTraceparent(r),
+		"chan", "data", "", ""))
+	assert.Equal(t, (<-requests).Items[0]["meta"], map[string]interface{}{
+		"traceparent": testTraceparent})
+}
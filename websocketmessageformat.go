@@ -7,11 +7,21 @@
 
 package gripcontrol
 
+import "encoding/base64"
+import "unicode/utf8"
+
 // The WebSocketMessageFormat struct is the format used to publish data to
-// WebSocket clients connected to GRIP proxies.
+// WebSocket clients connected to GRIP proxies. Setting Close causes the
+// connections to be closed with the optional Code and Reason instead of
+// receiving content. Type can be set to 'ping' or 'pong' to send a control
+// frame rather than a data message.
 type WebSocketMessageFormat struct {
 	Content []byte
 	Binary  bool
+	Type    string
+	Close   bool
+	Code    int
+	Reason  string
 }
 
 // The name used when publishing this format.
@@ -20,11 +30,28 @@ func (format *WebSocketMessageFormat) Name() string {
 }
 
 // Exports the message in the required format depending on whether the
-// binary field is set to true or false.
+// binary field is set to true or false, or whether the connection should
+// be closed. Binary content is exported as base64 as 'content-bin', and
+// content that is not valid UTF-8 is always exported that way regardless
+// of the binary field.
 func (format *WebSocketMessageFormat) Export() interface{} {
 	export := make(map[string]interface{})
-	if format.Binary {
-		export["content-bin"] = string(format.Content)
+	if format.Close {
+		export["action"] = "close"
+		if format.Code > 0 {
+			export["code"] = format.Code
+		}
+		if format.Reason != "" {
+			export["reason"] = format.Reason
+		}
+		return export
+	}
+	if format.Type != "" {
+		export["type"] = format.Type
+	}
+	if format.Binary || !utf8.Valid(format.Content) {
+		export["content-bin"] =
+			base64.StdEncoding.EncodeToString(format.Content)
 	} else {
 		export["content"] = string(format.Content)
 	}
@@ -8,6 +8,7 @@
 package gripcontrol
 
 import (
+	"encoding/base64"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -26,5 +27,22 @@ func TestWebSocketMessageFormatExport(t *testing.T) {
 		"content": "content"})
 	fmt = &WebSocketMessageFormat{Content: []byte("content"), Binary: true}
 	assert.Equal(t, fmt.Export(), map[string]interface{}{
-		"content-bin": "content"})
+		"content-bin": base64.StdEncoding.EncodeToString([]byte("content"))})
+	fmt = &WebSocketMessageFormat{
+		Content: []byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF")}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{"content-bin": base64.StdEncoding.EncodeToString(
+		[]byte("\xbd\xb2\x3d\xbc\x20\xe2\x8c\xFF"))})
+	fmt = &WebSocketMessageFormat{Content: []byte("content"), Close: true}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "close"})
+	fmt = &WebSocketMessageFormat{Close: true, Code: 1001,
+		Reason: "going away"}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"action": "close", "code": 1001, "reason": "going away"})
+	fmt = &WebSocketMessageFormat{Type: "ping"}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"type": "ping", "content": ""})
+	fmt = &WebSocketMessageFormat{Type: "pong", Content: []byte("data")}
+	assert.Equal(t, fmt.Export(), map[string]interface{}{
+		"type": "pong", "content": "data"})
 }